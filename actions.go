@@ -0,0 +1,109 @@
+package omxplayer
+
+// The actions that can be executed with the Action method. Their values match
+// the key actions defined in omxplayer's KeyConfig.h; the comments describe the
+// keys they are bound to by default.
+const (
+	ActionDecreaseSpeed         int32 = 1  // 1
+	ActionIncreaseSpeed         int32 = 2  // 2
+	ActionRewind                int32 = 3  // <
+	ActionFastForward           int32 = 4  // >
+	ActionShowInfo              int32 = 5  // z
+	ActionPreviousAudio         int32 = 6  // j
+	ActionNextAudio             int32 = 7  // k
+	ActionPreviousChapter       int32 = 8  // i
+	ActionNextChapter           int32 = 9  // o
+	ActionPreviousSubtitle      int32 = 10 // n
+	ActionNextSubtitle          int32 = 11 // m
+	ActionToggleSubtitle        int32 = 12 // s
+	ActionDecreaseSubtitleDelay int32 = 13 // d
+	ActionIncreaseSubtitleDelay int32 = 14 // f
+	ActionExit                  int32 = 15 // q
+	ActionPlayPause             int32 = 16 // p or space
+	ActionDecreaseVolume        int32 = 17 // -
+	ActionIncreaseVolume        int32 = 18 // + or =
+	ActionSeekBackSmall         int32 = 19 // left arrow
+	ActionSeekForwardSmall      int32 = 20 // right arrow
+	ActionSeekBackLarge         int32 = 21 // down arrow
+	ActionSeekForwardLarge      int32 = 22 // up arrow
+	ActionStep                  int32 = 23 // .
+	ActionBlank                 int32 = 24 // b
+	ActionSeekRelative          int32 = 25
+	ActionSeekAbsolute          int32 = 26
+	ActionMoveVideo             int32 = 27
+	ActionHideVideo             int32 = 28
+	ActionUnhideVideo           int32 = 29
+	ActionHideSubtitles         int32 = 30 // x
+	ActionShowSubtitles         int32 = 31 // w
+	ActionSetAlpha              int32 = 32
+	ActionSetAspectMode         int32 = 33
+	ActionCropVideo             int32 = 34
+	ActionPause                 int32 = 35
+	ActionPlay                  int32 = 36
+	ActionChangeFile            int32 = 37
+	ActionSetLayer              int32 = 38
+)
+
+// ShowInfo displays information about the video on screen, like the z key does.
+func (p *Player) ShowInfo() error {
+	return p.Action(ActionShowInfo)
+}
+
+// NextAudio switches to the next audio stream, like the k key does.
+func (p *Player) NextAudio() error {
+	return p.Action(ActionNextAudio)
+}
+
+// PreviousAudio switches to the previous audio stream, like the j key does.
+func (p *Player) PreviousAudio() error {
+	return p.Action(ActionPreviousAudio)
+}
+
+// NextChapter skips to the next chapter, like the o key does.
+func (p *Player) NextChapter() error {
+	if err := p.Action(ActionNextChapter); err != nil {
+		return err
+	}
+	p.stepChapter(1)
+	return nil
+}
+
+// PreviousChapter skips to the previous chapter, like the i key does.
+func (p *Player) PreviousChapter() error {
+	if err := p.Action(ActionPreviousChapter); err != nil {
+		return err
+	}
+	p.stepChapter(-1)
+	return nil
+}
+
+// NextSubtitle switches to the next subtitle stream, like the m key does.
+func (p *Player) NextSubtitle() error {
+	return p.Action(ActionNextSubtitle)
+}
+
+// PreviousSubtitle switches to the previous subtitle stream, like the n key
+// does.
+func (p *Player) PreviousSubtitle() error {
+	return p.Action(ActionPreviousSubtitle)
+}
+
+// SeekBackSmall seeks back 30 seconds, like the left arrow key does.
+func (p *Player) SeekBackSmall() error {
+	return p.Action(ActionSeekBackSmall)
+}
+
+// SeekForwardSmall seeks forward 30 seconds, like the right arrow key does.
+func (p *Player) SeekForwardSmall() error {
+	return p.Action(ActionSeekForwardSmall)
+}
+
+// SeekBackLarge seeks back 600 seconds, like the down arrow key does.
+func (p *Player) SeekBackLarge() error {
+	return p.Action(ActionSeekBackLarge)
+}
+
+// SeekForwardLarge seeks forward 600 seconds, like the up arrow key does.
+func (p *Player) SeekForwardLarge() error {
+	return p.Action(ActionSeekForwardLarge)
+}
@@ -0,0 +1,90 @@
+package omxplayer
+
+import "testing"
+
+// TestActionValues pins the values of the Action constants to those of
+// omxplayer's KeyConfig.h, which omxplayer expects over D-Bus.
+func TestActionValues(t *testing.T) {
+	tests := []struct {
+		name  string
+		value int32
+		want  int32
+	}{
+		{"DecreaseSpeed", ActionDecreaseSpeed, 1},
+		{"IncreaseSpeed", ActionIncreaseSpeed, 2},
+		{"Rewind", ActionRewind, 3},
+		{"FastForward", ActionFastForward, 4},
+		{"ShowInfo", ActionShowInfo, 5},
+		{"PreviousAudio", ActionPreviousAudio, 6},
+		{"NextAudio", ActionNextAudio, 7},
+		{"PreviousChapter", ActionPreviousChapter, 8},
+		{"NextChapter", ActionNextChapter, 9},
+		{"PreviousSubtitle", ActionPreviousSubtitle, 10},
+		{"NextSubtitle", ActionNextSubtitle, 11},
+		{"ToggleSubtitle", ActionToggleSubtitle, 12},
+		{"DecreaseSubtitleDelay", ActionDecreaseSubtitleDelay, 13},
+		{"IncreaseSubtitleDelay", ActionIncreaseSubtitleDelay, 14},
+		{"Exit", ActionExit, 15},
+		{"PlayPause", ActionPlayPause, 16},
+		{"DecreaseVolume", ActionDecreaseVolume, 17},
+		{"IncreaseVolume", ActionIncreaseVolume, 18},
+		{"SeekBackSmall", ActionSeekBackSmall, 19},
+		{"SeekForwardSmall", ActionSeekForwardSmall, 20},
+		{"SeekBackLarge", ActionSeekBackLarge, 21},
+		{"SeekForwardLarge", ActionSeekForwardLarge, 22},
+		{"Step", ActionStep, 23},
+		{"Blank", ActionBlank, 24},
+		{"SeekRelative", ActionSeekRelative, 25},
+		{"SeekAbsolute", ActionSeekAbsolute, 26},
+		{"MoveVideo", ActionMoveVideo, 27},
+		{"HideVideo", ActionHideVideo, 28},
+		{"UnhideVideo", ActionUnhideVideo, 29},
+		{"HideSubtitles", ActionHideSubtitles, 30},
+		{"ShowSubtitles", ActionShowSubtitles, 31},
+		{"SetAlpha", ActionSetAlpha, 32},
+		{"SetAspectMode", ActionSetAspectMode, 33},
+		{"CropVideo", ActionCropVideo, 34},
+		{"Pause", ActionPause, 35},
+		{"Play", ActionPlay, 36},
+		{"ChangeFile", ActionChangeFile, 37},
+		{"SetLayer", ActionSetLayer, 38},
+	}
+	for _, tt := range tests {
+		if tt.value != tt.want {
+			t.Errorf("Action%s = %d, want %d", tt.name, tt.value, tt.want)
+		}
+	}
+}
+
+func TestActionMethods(t *testing.T) {
+	tests := []struct {
+		name   string
+		fn     func(*Player) error
+		action int32
+	}{
+		{"ShowInfo", (*Player).ShowInfo, ActionShowInfo},
+		{"NextAudio", (*Player).NextAudio, ActionNextAudio},
+		{"PreviousAudio", (*Player).PreviousAudio, ActionPreviousAudio},
+		{"NextChapter", (*Player).NextChapter, ActionNextChapter},
+		{"PreviousChapter", (*Player).PreviousChapter, ActionPreviousChapter},
+		{"NextSubtitle", (*Player).NextSubtitle, ActionNextSubtitle},
+		{"PreviousSubtitle", (*Player).PreviousSubtitle, ActionPreviousSubtitle},
+		{"SeekBackSmall", (*Player).SeekBackSmall, ActionSeekBackSmall},
+		{"SeekForwardSmall", (*Player).SeekForwardSmall, ActionSeekForwardSmall},
+		{"SeekBackLarge", (*Player).SeekBackLarge, ActionSeekBackLarge},
+		{"SeekForwardLarge", (*Player).SeekForwardLarge, ActionSeekForwardLarge},
+	}
+	for _, tt := range tests {
+		bus := newFakeCaller()
+		bus.respond(cmdAction)
+		p := newTestPlayer(t, bus)
+
+		if err := tt.fn(p); err != nil {
+			t.Errorf("%s: returned error: %v", tt.name, err)
+			continue
+		}
+		if actions := bus.actions(); !equalActions(actions, []int32{tt.action}) {
+			t.Errorf("%s: sent actions %v, want [%d]", tt.name, actions, tt.action)
+		}
+	}
+}
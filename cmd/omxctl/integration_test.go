@@ -0,0 +1,92 @@
+//go:build integration
+// +build integration
+
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/17xande/omxplayer"
+)
+
+// testBusName is the D-Bus name of the omxplayer instance the tests launch, so
+// that they do not interfere with another running instance.
+const testBusName = "org.mpris.MediaPlayer2.omxplayer.omxctltest"
+
+// launchPlayer launches omxplayer on the video named by the OMXPLAYER_TEST_VIDEO
+// environment variable and returns it once it is ready. The test is skipped if
+// the variable is not set.
+func launchPlayer(t *testing.T) *omxplayer.Player {
+	t.Helper()
+
+	video := os.Getenv("OMXPLAYER_TEST_VIDEO")
+	if video == "" {
+		t.Skip("OMXPLAYER_TEST_VIDEO is not set")
+	}
+	player, err := omxplayer.NewWithOptions(video,
+		omxplayer.WithDBusName(testBusName),
+		omxplayer.WithDBusTimeout(10*time.Second),
+		omxplayer.WithNoKeys(),
+		omxplayer.WithNoOSD(),
+	)
+	if err != nil {
+		t.Fatalf("launching omxplayer: %v", err)
+	}
+	return player
+}
+
+func TestRun(t *testing.T) {
+	launched := launchPlayer(t)
+	defer launched.Close()
+
+	player, err := omxplayer.Connect("", testBusName)
+	if err != nil {
+		t.Fatalf("connecting to omxplayer: %v", err)
+	}
+	defer player.Close()
+
+	tests := []struct {
+		command string
+		args    []string
+		status  string
+	}{
+		{"pause", nil, "Paused"},
+		{"pause", nil, "Paused"},
+		{"play", nil, "Playing"},
+		{"play", nil, "Playing"},
+		{"seek", []string{"1s"}, "Playing"},
+		{"volume", []string{"0.5"}, "Playing"},
+		{"volume", nil, "Playing"},
+		{"status", nil, "Playing"},
+	}
+	for _, tt := range tests {
+		if err = run(player, tt.command, tt.args); err != nil {
+			t.Fatalf("%s %v: returned error: %v", tt.command, tt.args, err)
+		}
+		if status, err := player.PlaybackStatus(); err != nil || status != tt.status {
+			t.Errorf("%s %v: got status %q, %v, want %q", tt.command, tt.args, status, err, tt.status)
+		}
+	}
+
+	if volume, err := launched.Volume(); err != nil || volume != 0.5 {
+		t.Errorf("got volume %v, %v, want 0.5 as set through omxctl", volume, err)
+	}
+
+	for _, args := range [][]string{{"bogus"}, {"seek"}, {"seek", "soon"}, {"volume", "loud"}} {
+		if err = run(player, args[0], args[1:]); err == nil {
+			t.Errorf("%v: returned no error", args)
+		}
+	}
+
+	if err = run(player, "stop", nil); err != nil {
+		t.Fatalf("stop: returned error: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err = launched.WaitForExit(ctx); err == context.DeadlineExceeded {
+		t.Error("omxplayer did not exit after stop")
+	}
+}
@@ -0,0 +1,104 @@
+// Command omxctl controls an omxplayer instance that is already running, for
+// example over SSH.
+//
+// Usage:
+//
+//	omxctl [-address addr] [-name name] command [argument]
+//
+// The commands are play, pause, stop, next, prev, status, seek with a position
+// such as 1m30s, and volume with an optional level, where 1 is full volume.
+// Without a level, volume prints the current volume.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/17xande/omxplayer"
+)
+
+func main() {
+	address := flag.String("address", "", "D-Bus address of omxplayer's session bus (default: read from omxplayer's D-Bus files)")
+	name := flag.String("name", "", "D-Bus name of the omxplayer instance (default: org.mpris.MediaPlayer2.omxplayer)")
+	flag.Usage = usage
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+
+	player, err := omxplayer.Connect(*address, *name)
+	if err != nil {
+		fail(err)
+	}
+	defer player.Close()
+
+	if err = run(player, flag.Arg(0), flag.Args()[1:]); err != nil {
+		player.Close()
+		fail(err)
+	}
+}
+
+// run executes the specified command with its arguments.
+func run(player *omxplayer.Player, command string, args []string) error {
+	switch command {
+	case "play":
+		return player.Resume()
+	case "pause":
+		return player.PauseOnly()
+	case "stop":
+		return player.Stop()
+	case "next":
+		return player.Next()
+	case "prev":
+		return player.Previous()
+	case "seek":
+		if len(args) != 1 {
+			return fmt.Errorf("seek requires a position")
+		}
+		pos, err := time.ParseDuration(args[0])
+		if err != nil {
+			return err
+		}
+		_, err = player.SeekTo(pos)
+		return err
+	case "volume":
+		if len(args) == 0 {
+			volume, err := player.Volume()
+			if err == nil {
+				fmt.Println(volume)
+			}
+			return err
+		}
+		level, err := strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return err
+		}
+		_, err = player.Volume(level)
+		return err
+	case "status":
+		status, err := player.Status()
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(status)
+	}
+	return fmt.Errorf("unknown command: %s", command)
+}
+
+func usage() {
+	fmt.Fprintln(flag.CommandLine.Output(), "usage: omxctl [-address addr] [-name name] play|pause|stop|next|prev|status|seek pos|volume [level]")
+	flag.PrintDefaults()
+}
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "omxctl:", err)
+	os.Exit(1)
+}
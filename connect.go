@@ -0,0 +1,64 @@
+package omxplayer
+
+import (
+	"context"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// Connect returns a Player that controls an omxplayer instance that is already
+// running, rather than launching a new one. address is the D-Bus address of the
+// session bus omxplayer is connected to; if it is empty, the address omxplayer
+// wrote to its D-Bus files is used, see SetUser. name is the bus name omxplayer
+// registered, which is changed with omxplayer's --dbus_name flag; if it is
+// empty, omxplayer's default name is used.
+//
+// Since the process was not started by this Player, Close only closes the D-Bus
+// connection and leaves omxplayer running, and Kill and QuitWithTimeout return
+// ErrNotLaunched. Wait and WaitForExit do not return until they are cancelled.
+func Connect(address, name string) (*Player, error) {
+	if address == "" {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultDbusTimeout)
+		defer cancel()
+
+		var err error
+		if address, err = getDbusPath(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	conn, err := dialDbus(address)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{dbusAddress: address, dbusName: name}
+	return &Player{
+		connection: conn,
+		bus:        conn.Object(config.busName(), pathMpris),
+		config:     config,
+		output:     newProcessOutput(config),
+		exited:     make(chan struct{}),
+	}, nil
+}
+
+// dialDbus opens a private connection to the D-Bus bus at the specified address
+// and authenticates it.
+func dialDbus(address string) (conn *dbus.Conn, err error) {
+	debugf("omxplayer: opening dbus connection: address=%s", address)
+	if conn, err = dbus.Dial(address); err != nil {
+		return
+	}
+
+	debugf("omxplayer: authenticating dbus connection")
+	if err = conn.Auth(dbusAuthMethods()); err != nil {
+		conn.Close()
+		return
+	}
+
+	debugf("omxplayer: initializing dbus connection")
+	if err = conn.Hello(); err != nil {
+		conn.Close()
+	}
+	return
+}
@@ -0,0 +1,123 @@
+package omxplayer
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+const (
+	cmdPing = "org.freedesktop.DBus.Peer.Ping"
+
+	errServiceUnknown = "org.freedesktop.DBus.Error.ServiceUnknown"
+	errNameHasNoOwner = "org.freedesktop.DBus.Error.NameHasNoOwner"
+	errDisconnected   = "org.freedesktop.DBus.Error.Disconnected"
+)
+
+// monitorConnection checks the health of the D-Bus connection at the specified
+// interval for as long as the omxplayer process is running. When the connection
+// has died, it is re-established and the result of the attempt is sent through
+// events, if it is not nil. Sends that would block are dropped.
+func (p *Player) monitorConnection(interval time.Duration, events chan<- error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.exited:
+			return
+		case <-ticker.C:
+		}
+
+		if p.connectionAlive() {
+			continue
+		}
+
+		err := p.Reconnect()
+		p.debugf("omxplayer: re-established dbus connection: error=%v", err)
+
+		if events != nil {
+			select {
+			case events <- err:
+			default:
+			}
+		}
+	}
+}
+
+// connectionAlive returns true if the D-Bus daemon still responds over the
+// player's connection, regardless of whether omxplayer itself responds.
+func (p *Player) connectionAlive() bool {
+	p.mu.RLock()
+	conn := p.connection
+	p.mu.RUnlock()
+
+	return conn.BusObject().Call(cmdPing, 0).Err == nil
+}
+
+// Reconnect establishes a new D-Bus connection to omxplayer and replaces the
+// player's connection with it, for example after the session bus restarted.
+// The connection is made to the address passed to Connect or, if there was
+// none, to the address omxplayer wrote to its D-Bus files. ErrStopped is
+// returned if the omxplayer process launched by the player has exited.
+func (p *Player) Reconnect() error {
+	select {
+	case <-p.exited:
+		return ErrStopped
+	default:
+	}
+
+	conn, bus, err := dial(p)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	old := p.connection
+	p.connection = conn
+	p.bus = bus
+	p.mu.Unlock()
+
+	if old != nil {
+		old.Close()
+	}
+	return nil
+}
+
+// dial opens a new D-Bus connection to the omxplayer instance controlled by p
+// and returns it along with the object omxplayer is called through. It is a
+// variable so that tests can replace the connection with a fake.
+var dial = func(p *Player) (*dbus.Conn, caller, error) {
+	conn, err := p.dialOmxplayer()
+	if err != nil {
+		return nil, nil, err
+	}
+	return conn, conn.Object(p.config.busName(), pathMpris), nil
+}
+
+// dialOmxplayer opens and authenticates a new D-Bus connection to the bus
+// omxplayer is connected to.
+func (p *Player) dialOmxplayer() (*dbus.Conn, error) {
+	if p.config.dbusAddress != "" {
+		return dialDbus(p.config.dbusAddress)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.config.connectTimeout())
+	defer cancel()
+
+	if err := setupDbusEnvironment(ctx); err != nil {
+		return nil, err
+	}
+	return getDbusConnection()
+}
+
+// connectionLost returns true if err indicates that the D-Bus connection to
+// omxplayer was lost, as opposed to omxplayer rejecting a call.
+func connectionLost(err error) bool {
+	return errors.Is(err, dbus.ErrClosed) ||
+		isDBusError(err, errServiceUnknown) ||
+		isDBusError(err, errNameHasNoOwner) ||
+		isDBusError(err, errDisconnected)
+}
@@ -0,0 +1,96 @@
+package omxplayer
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+func TestReconnect(t *testing.T) {
+	dropped := newFakeCaller()
+	dropped.fail(propPlaybackStatus, dbus.Error{Name: errNameHasNoOwner})
+	restored := newFakeCaller()
+	restored.respond(propPlaybackStatus, "Playing")
+	dials, restore := replaceDial(restored)
+	defer restore()
+	p := newTestPlayer(t, dropped)
+
+	_, err := p.PlaybackStatus()
+	if !connectionLost(err) {
+		t.Fatalf("got error %v, want it to report the lost connection", err)
+	}
+
+	if err = p.Reconnect(); err != nil {
+		t.Fatal(err)
+	}
+	if *dials != 1 {
+		t.Errorf("dialled %d times, want 1", *dials)
+	}
+
+	status, err := p.PlaybackStatus()
+	if err != nil {
+		t.Fatalf("got error %v after reconnecting", err)
+	}
+	if status != "Playing" {
+		t.Errorf("got status %q, want Playing", status)
+	}
+	if n := len(dropped.callsTo(propPlaybackStatus)); n != 1 {
+		t.Errorf("got %d calls on the dropped connection, want 1", n)
+	}
+}
+
+func TestReconnectStopped(t *testing.T) {
+	dials, restore := replaceDial(newFakeCaller())
+	defer restore()
+	p := newTestPlayer(t, newFakeCaller())
+	close(p.exited)
+
+	if err := p.Reconnect(); !errors.Is(err, ErrStopped) {
+		t.Errorf("got error %v, want ErrStopped", err)
+	}
+	if *dials != 0 {
+		t.Errorf("dialled %d times after the process exited, want 0", *dials)
+	}
+}
+
+func TestConnectionLost(t *testing.T) {
+	lost := []error{
+		dbus.ErrClosed,
+		dbus.Error{Name: errServiceUnknown},
+		dbus.Error{Name: errNameHasNoOwner},
+		dbus.Error{Name: errDisconnected},
+		&wrappedError{ErrNotReady, dbus.Error{Name: errServiceUnknown}},
+	}
+	for _, err := range lost {
+		if !connectionLost(err) {
+			t.Errorf("connectionLost(%v) = false, want true", err)
+		}
+	}
+
+	kept := []error{
+		dbus.Error{Name: errUnknownMethod},
+		dbus.Error{Name: errInvalidArgs},
+		errors.New("failed"),
+	}
+	for _, err := range kept {
+		if connectionLost(err) {
+			t.Errorf("connectionLost(%v) = true, want false", err)
+		}
+	}
+}
+
+func TestConnectTimeout(t *testing.T) {
+	if timeout := (&Config{}).connectTimeout(); timeout != defaultDbusTimeout {
+		t.Errorf("got default timeout %s, want %s", timeout, defaultDbusTimeout)
+	}
+
+	config := &Config{}
+	if err := WithDBusTimeout(time.Second)(config); err != nil {
+		t.Fatal(err)
+	}
+	if timeout := config.connectTimeout(); timeout != time.Second {
+		t.Errorf("got timeout %s, want the one set with WithDBusTimeout", timeout)
+	}
+}
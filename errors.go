@@ -0,0 +1,115 @@
+package omxplayer
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrNotReady is returned when a D-Bus call fails because the player is not
+// ready to accept D-Bus commands yet. The underlying D-Bus error is preserved
+// and can be retrieved with errors.Unwrap.
+var ErrNotReady = errors.New("omxplayer: player is not ready")
+
+// ErrQuitTimeout is returned by QuitWithTimeout when omxplayer did not exit in
+// time after being asked to quit and had to be terminated with a signal.
+var ErrQuitTimeout = errors.New("omxplayer: process did not quit in time")
+
+// ErrPassthrough is returned when changing the volume of a player that passes
+// its audio through undecoded, since omxplayer cannot change its volume.
+var ErrPassthrough = errors.New("omxplayer: volume cannot be changed in passthrough mode")
+
+// ErrInvalidRect is returned when a rectangle has negative coordinates or its
+// bottom-right corner is not below and to the right of its top-left corner.
+var ErrInvalidRect = errors.New("omxplayer: invalid rectangle")
+
+// ErrSubtitlesNotFound is returned by New when the subtitle file passed to
+// WithSubtitles does not exist, since omxplayer itself ignores it silently. The
+// underlying error from os.Stat can be retrieved with errors.Unwrap.
+var ErrSubtitlesNotFound = errors.New("omxplayer: subtitle file not found")
+
+// ErrExecutableNotFound is returned by New when the omxplayer executable cannot
+// be found or is not executable. The underlying error from exec.LookPath can be
+// retrieved with errors.Unwrap.
+var ErrExecutableNotFound = errors.New("omxplayer: executable not found")
+
+// ErrSourceNotFound is returned by New when the file to be played does not
+// exist. The underlying error from os.Stat can be retrieved with errors.Unwrap.
+var ErrSourceNotFound = errors.New("omxplayer: source not found")
+
+// ErrUnsupportedScheme is returned by New when the URL to be played has a scheme
+// omxplayer cannot open.
+var ErrUnsupportedScheme = errors.New("omxplayer: unsupported url scheme")
+
+// ErrRateOutOfRange is returned by SetRate when the requested rate is outside
+// the range supported by the player.
+var ErrRateOutOfRange = errors.New("omxplayer: rate out of range")
+
+// ErrUnsupportedMethod is returned when the omxplayer build does not implement
+// the D-Bus method a Player method relies on. The underlying D-Bus error can be
+// retrieved with errors.Unwrap.
+var ErrUnsupportedMethod = errors.New("omxplayer: method not supported by this omxplayer build")
+
+// ErrUnsupported is returned by methods that omxplayer provides no means of
+// implementing.
+var ErrUnsupported = errors.New("omxplayer: not supported by omxplayer")
+
+// ErrTrackNotFound is returned when no stream matches the one requested, for
+// example by SelectSubtitleByLang.
+var ErrTrackNotFound = errors.New("omxplayer: track not found")
+
+// ErrStopped is returned by methods that need a video to be loaded when the
+// player has stopped or its process has exited.
+var ErrStopped = errors.New("omxplayer: player is stopped")
+
+// ErrNotLaunched is returned when signalling the omxplayer process of a Player
+// returned by Connect, since that process was not launched by the Player.
+var ErrNotLaunched = errors.New("omxplayer: process was not launched by this player")
+
+// MultiError is returned by methods that perform several D-Bus calls and carry
+// on past the ones that fail. It holds every error that occurred, in order.
+type MultiError []error
+
+// Error returns the messages of all of the errors, separated by semicolons.
+func (m MultiError) Error() string {
+	messages := make([]string, len(m))
+	for i, err := range m {
+		messages[i] = err.Error()
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Unwrap returns the errors held by m.
+func (m MultiError) Unwrap() []error {
+	return m
+}
+
+// errorOrNil returns nil if m holds no errors, and m otherwise.
+func (m MultiError) errorOrNil() error {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
+
+// wrappedError annotates an underlying error with one of the package's sentinel
+// errors, so that errors.Is matches the sentinel while the underlying error can
+// still be inspected with errors.Unwrap and errors.As.
+type wrappedError struct {
+	sentinel error
+	err      error
+}
+
+// Error returns the messages of the sentinel and the underlying error.
+func (e *wrappedError) Error() string {
+	return e.sentinel.Error() + ": " + e.err.Error()
+}
+
+// Is returns true if target is the sentinel error.
+func (e *wrappedError) Is(target error) bool {
+	return target == e.sentinel
+}
+
+// Unwrap returns the underlying error.
+func (e *wrappedError) Unwrap() error {
+	return e.err
+}
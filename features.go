@@ -0,0 +1,108 @@
+package omxplayer
+
+import (
+	"encoding/xml"
+
+	"github.com/godbus/dbus/v5/introspect"
+)
+
+const cmdIntrospect = "org.freedesktop.DBus.Introspectable.Introspect"
+
+// features maps the name of each Player method that wraps a D-Bus method or
+// property to the name of the member it relies on.
+var features = map[string]string{
+	"Quit":                "Quit",
+	"CanQuit":             "CanQuit",
+	"Fullscreen":          "Fullscreen",
+	"CanSetFullscreen":    "CanSetFullscreen",
+	"CanRaise":            "CanRaise",
+	"HasTrackList":        "HasTrackList",
+	"Identity":            "Identity",
+	"SupportedURISchemes": "SupportedUriSchemes",
+	"SupportedMimeTypes":  "SupportedMimeTypes",
+	"CanGoNext":           "CanGoNext",
+	"CanGoPrevious":       "CanGoPrevious",
+	"CanSeek":             "CanSeek",
+	"CanControl":          "CanControl",
+	"CanPlay":             "CanPlay",
+	"CanPause":            "CanPause",
+	"Next":                "Next",
+	"Previous":            "Previous",
+	"Pause":               "Pause",
+	"Play":                "Play",
+	"PlayPause":           "PlayPause",
+	"Stop":                "Stop",
+	"Seek":                "Seek",
+	"SetPosition":         "SetPosition",
+	"PlaybackStatus":      "PlaybackStatus",
+	"Volume":              "Volume",
+	"Mute":                "Mute",
+	"Unmute":              "Unmute",
+	"Position":            "Position",
+	"Aspect":              "Aspect",
+	"VideoStreamCount":    "VideoStreamCount",
+	"ResWidth":            "ResWidth",
+	"ResHeight":           "ResHeight",
+	"Duration":            "Duration",
+	"MinimumRate":         "MinimumRate",
+	"MaximumRate":         "MaximumRate",
+	"ListSubtitles":       "ListSubtitles",
+	"HideVideo":           "HideVideo",
+	"UnHideVideo":         "UnHideVideo",
+	"ListAudio":           "ListAudio",
+	"ListVideo":           "ListVideo",
+	"SelectSubtitle":      "SelectSubtitle",
+	"SelectAudio":         "SelectAudio",
+	"ShowSubtitles":       "ShowSubtitles",
+	"HideSubtitles":       "HideSubtitles",
+	"Action":              "Action",
+	"SetVideoPos":         "SetVideoPos",
+	"SetAlpha":            "SetAlpha",
+	"SetAspectMode":       "SetAspectMode",
+	"GetSource":           "GetSource",
+	"Rate":                "Rate",
+	"SetRate":             "Rate",
+	"Metadata":            "Metadata",
+	"SetVideoCrop":        "SetVideoCropPos",
+	"SetLayer":            "SetLayer",
+	"OpenURI":             "OpenUri",
+}
+
+// FeatureReport reports, for each Player method that wraps a D-Bus method or
+// property, whether the running omxplayer build supports it. The report is keyed
+// by the name of the Go method and is derived from the interface description
+// the player publishes through D-Bus introspection, so members the build does
+// not describe are reported as unsupported.
+func (p *Player) FeatureReport() (map[string]bool, error) {
+	p.debugf("omxplayer: dbus call %s", cmdIntrospect)
+	call := p.call(cmdIntrospect)
+	if call.Err != nil {
+		return nil, call.Err
+	}
+
+	var data string
+	if err := call.Store(&data); err != nil {
+		return nil, err
+	}
+
+	var node introspect.Node
+	if err := xml.Unmarshal([]byte(data), &node); err != nil {
+		return nil, err
+	}
+
+	members := make(map[string]bool)
+	for _, iface := range node.Interfaces {
+		for _, method := range iface.Methods {
+			members[method.Name] = true
+		}
+		for _, prop := range iface.Properties {
+			members[prop.Name] = true
+		}
+	}
+
+	report := make(map[string]bool, len(features))
+	for method, member := range features {
+		report[method] = members[member]
+	}
+	return report, nil
+}
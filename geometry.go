@@ -0,0 +1,46 @@
+package omxplayer
+
+import (
+	"fmt"
+)
+
+// AspectMode controls how omxplayer fits a video whose aspect ratio differs from
+// that of the screen or window it is played in.
+type AspectMode string
+
+// The aspect modes supported by omxplayer.
+const (
+	AspectLetterbox AspectMode = "letterbox"
+	AspectFill      AspectMode = "fill"
+	AspectStretch   AspectMode = "stretch"
+)
+
+// validate returns an error if m is not an aspect mode omxplayer supports.
+func (m AspectMode) validate() error {
+	switch m {
+	case AspectLetterbox, AspectFill, AspectStretch:
+		return nil
+	}
+	return fmt.Errorf("omxplayer: invalid aspect mode: %q", m)
+}
+
+// Rect is a rectangle on the screen, described by the coordinates of its
+// top-left (X1, Y1) and bottom-right (X2, Y2) corners in pixels.
+type Rect struct {
+	X1, Y1, X2, Y2 int
+}
+
+// validate returns an error wrapping ErrInvalidRect if r has negative
+// coordinates or is empty.
+func (r Rect) validate() error {
+	return validateRect(int64(r.X1), int64(r.Y1), int64(r.X2), int64(r.Y2))
+}
+
+// validateRect returns an error wrapping ErrInvalidRect if the rectangle with
+// the specified corners has negative coordinates or is empty.
+func validateRect(x1, y1, x2, y2 int64) error {
+	if x1 < 0 || y1 < 0 || x2 <= x1 || y2 <= y1 {
+		return fmt.Errorf("%w: %d,%d,%d,%d", ErrInvalidRect, x1, y1, x2, y2)
+	}
+	return nil
+}
@@ -2,7 +2,4 @@ module github.com/17xande/omxplayer
 
 go 1.13
 
-require (
-	github.com/godbus/dbus/v5 v5.0.3
-	github.com/sirupsen/logrus v1.4.2
-)
+require github.com/godbus/dbus/v5 v5.0.3
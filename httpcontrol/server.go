@@ -0,0 +1,201 @@
+// Package httpcontrol exposes an omxplayer Player over HTTP, so that it can be
+// controlled remotely, for example from a phone. It lives in its own package so
+// that programs that do not need it do not depend on net/http.
+package httpcontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/17xande/omxplayer"
+)
+
+// HTTPServer serves the following endpoints to control a Player:
+//
+//	POST /play              resumes playback
+//	POST /pause             pauses playback
+//	POST /stop              stops playback, which terminates omxplayer
+//	POST /seek?pos=1m30s    seeks to an absolute position, given as a Go
+//	                        duration or a number of seconds
+//	POST /volume?level=0.5  sets the volume, where 1 is full volume
+//	GET  /status            returns the player's Status as JSON
+//
+// Successful commands respond with 204 No Content. Failures respond with an
+// error status and the error message as plain text. If only some of the status
+// could be read, for example because audio-only media has no resolution, the
+// status is still returned, with the fields that could not be read left at
+// zero and the failures listed in an additional errors field.
+type HTTPServer struct {
+	player *omxplayer.Player
+	server *http.Server
+}
+
+// NewHTTPServer returns an HTTPServer that controls p and listens on the TCP
+// network address addr once ListenAndServe is called.
+func NewHTTPServer(p *omxplayer.Player, addr string) *HTTPServer {
+	s := &HTTPServer{player: p}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/play", command(func(*http.Request) error { return p.Resume() }))
+	mux.HandleFunc("/pause", command(func(*http.Request) error { return p.PauseOnly() }))
+	mux.HandleFunc("/stop", command(func(*http.Request) error { return p.Stop() }))
+	mux.HandleFunc("/seek", command(s.seek))
+	mux.HandleFunc("/volume", command(s.volume))
+	mux.HandleFunc("/status", s.status)
+
+	s.server = &http.Server{Addr: addr, Handler: mux}
+	return s
+}
+
+// ListenAndServe listens on the server's address and serves requests until the
+// server is shut down, in which case http.ErrServerClosed is returned.
+func (s *HTTPServer) ListenAndServe() error {
+	return s.server.ListenAndServe()
+}
+
+// Shutdown stops the server gracefully, waiting for active requests to finish
+// until ctx is done. The player is left running.
+func (s *HTTPServer) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// ServeHTTP serves a request, which allows the server to be mounted into
+// another handler instead of using ListenAndServe.
+func (s *HTTPServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.server.Handler.ServeHTTP(w, r)
+}
+
+// seek seeks to the position in the pos parameter.
+func (s *HTTPServer) seek(r *http.Request) error {
+	value := r.FormValue("pos")
+	pos, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, perr := strconv.ParseFloat(value, 64)
+		if perr != nil {
+			return badRequest(fmt.Errorf("invalid position: %q", value))
+		}
+		pos = time.Duration(seconds * float64(time.Second))
+	}
+
+	_, err = s.player.SeekTo(pos)
+	return err
+}
+
+// volume sets the volume to the value of the level parameter.
+func (s *HTTPServer) volume(r *http.Request) error {
+	value := r.FormValue("level")
+	level, err := strconv.ParseFloat(value, 64)
+	if err != nil || level < 0 {
+		return badRequest(fmt.Errorf("invalid volume level: %q", value))
+	}
+
+	_, err = s.player.Volume(level)
+	return err
+}
+
+// status responds with the player's status as JSON.
+func (s *HTTPServer) status(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", http.MethodGet)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	status, err := s.player.Status()
+	writeStatus(w, status, err)
+}
+
+// writeStatus responds with status as JSON. If err is a MultiError and some of
+// the status could still be read, the messages of the errors are added to the
+// JSON in an errors field. Any other error is reported as a failure.
+func writeStatus(w http.ResponseWriter, status omxplayer.Status, err error) {
+	var body interface{} = status
+	if err != nil {
+		var errs omxplayer.MultiError
+		if !errors.As(err, &errs) || status == (omxplayer.Status{}) {
+			http.Error(w, err.Error(), statusCode(err))
+			return
+		}
+
+		partial, perr := partialStatus(status, errs)
+		if perr != nil {
+			http.Error(w, perr.Error(), http.StatusInternalServerError)
+			return
+		}
+		body = partial
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(body)
+}
+
+// partialStatus returns the JSON fields of status along with an errors field
+// that lists the messages of errs.
+func partialStatus(status omxplayer.Status, errs omxplayer.MultiError) (map[string]interface{}, error) {
+	data, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err = json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+
+	messages := make([]string, len(errs))
+	for i, err := range errs {
+		messages[i] = err.Error()
+	}
+	fields["errors"] = messages
+	return fields, nil
+}
+
+// command returns a handler that only accepts POST requests and responds with
+// the outcome of fn.
+func command(fn func(r *http.Request) error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if err := fn(r); err != nil {
+			http.Error(w, err.Error(), statusCode(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// requestError is an error caused by an invalid request.
+type requestError struct {
+	err error
+}
+
+// badRequest marks err as caused by an invalid request.
+func badRequest(err error) error {
+	return &requestError{err}
+}
+
+// Error returns the message of the underlying error.
+func (e *requestError) Error() string {
+	return e.err.Error()
+}
+
+// statusCode returns the HTTP status code that reports err.
+func statusCode(err error) int {
+	var reqErr *requestError
+	switch {
+	case errors.As(err, &reqErr):
+		return http.StatusBadRequest
+	case errors.Is(err, omxplayer.ErrNotReady):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, omxplayer.ErrUnsupportedMethod), errors.Is(err, omxplayer.ErrPassthrough):
+		return http.StatusNotImplemented
+	}
+	return http.StatusBadGateway
+}
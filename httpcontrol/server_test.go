@@ -0,0 +1,88 @@
+package httpcontrol
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/17xande/omxplayer"
+)
+
+func TestWriteStatus(t *testing.T) {
+	status := omxplayer.Status{
+		PlaybackStatus: "Playing",
+		Playing:        true,
+		Position:       90 * time.Second,
+		Volume:         0.5,
+	}
+
+	rec := httptest.NewRecorder()
+	writeStatus(rec, status, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status code %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var fields map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["playbackStatus"] != "playing" || fields["positionMs"] != 90000.0 {
+		t.Errorf("got %s, want the encoded status", rec.Body)
+	}
+	if _, ok := fields["errors"]; ok {
+		t.Errorf("got errors field in %s, want none", rec.Body)
+	}
+}
+
+func TestWriteStatusPartial(t *testing.T) {
+	status := omxplayer.Status{PlaybackStatus: "Playing", Playing: true, Volume: 0.5}
+	errs := omxplayer.MultiError{errors.New("no width"), errors.New("no height")}
+
+	rec := httptest.NewRecorder()
+	writeStatus(rec, status, errs)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got status code %d, want %d", rec.Code, http.StatusOK)
+	}
+
+	var body struct {
+		PlaybackStatus string   `json:"playbackStatus"`
+		Volume         float64  `json:"volume"`
+		Errors         []string `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatal(err)
+	}
+	if body.PlaybackStatus != "playing" || body.Volume != 0.5 {
+		t.Errorf("got %s, want the fields that were read", rec.Body)
+	}
+	if len(body.Errors) != 2 || body.Errors[0] != "no width" || body.Errors[1] != "no height" {
+		t.Errorf("got errors %q, want both failures", body.Errors)
+	}
+}
+
+func TestWriteStatusFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+	}{
+		{"nothing read", omxplayer.MultiError{omxplayer.ErrNotReady}, http.StatusServiceUnavailable},
+		{"other error", errors.New("failed"), http.StatusBadGateway},
+	}
+
+	for _, tt := range tests {
+		status := omxplayer.Status{}
+		if tt.name == "other error" {
+			status.PlaybackStatus = "Playing"
+		}
+
+		rec := httptest.NewRecorder()
+		writeStatus(rec, status, tt.err)
+		if rec.Code != tt.code {
+			t.Errorf("%s: got status code %d, want %d", tt.name, rec.Code, tt.code)
+		}
+	}
+}
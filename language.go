@@ -0,0 +1,84 @@
+package omxplayer
+
+import "strings"
+
+// languageCodes maps the ISO 639-1 code of common languages to their ISO 639-2
+// codes. Where the bibliographic (B) and terminological (T) codes differ, both
+// are listed, since containers use either.
+var languageCodes = map[string][]string{
+	"ar": {"ara"},
+	"bg": {"bul"},
+	"bo": {"tib", "bod"},
+	"ca": {"cat"},
+	"cs": {"cze", "ces"},
+	"cy": {"wel", "cym"},
+	"da": {"dan"},
+	"de": {"ger", "deu"},
+	"el": {"gre", "ell"},
+	"en": {"eng"},
+	"es": {"spa"},
+	"et": {"est"},
+	"eu": {"baq", "eus"},
+	"fa": {"per", "fas"},
+	"fi": {"fin"},
+	"fr": {"fre", "fra"},
+	"ga": {"gle"},
+	"he": {"heb"},
+	"hi": {"hin"},
+	"hr": {"hrv"},
+	"hu": {"hun"},
+	"hy": {"arm", "hye"},
+	"id": {"ind"},
+	"is": {"ice", "isl"},
+	"it": {"ita"},
+	"ja": {"jpn"},
+	"ka": {"geo", "kat"},
+	"ko": {"kor"},
+	"lt": {"lit"},
+	"lv": {"lav"},
+	"mi": {"mao", "mri"},
+	"mk": {"mac", "mkd"},
+	"ms": {"may", "msa"},
+	"my": {"bur", "mya"},
+	"nb": {"nob"},
+	"nl": {"dut", "nld"},
+	"no": {"nor"},
+	"pl": {"pol"},
+	"pt": {"por"},
+	"ro": {"rum", "ron"},
+	"ru": {"rus"},
+	"sk": {"slo", "slk"},
+	"sl": {"slv"},
+	"sq": {"alb", "sqi"},
+	"sr": {"srp"},
+	"sv": {"swe"},
+	"th": {"tha"},
+	"tr": {"tur"},
+	"uk": {"ukr"},
+	"vi": {"vie"},
+	"zh": {"chi", "zho"},
+}
+
+// canonicalLanguage returns the lower case ISO 639-1 code of the language with
+// the specified ISO 639-1 or 639-2 code, or the lower case code itself if the
+// language is not known.
+func canonicalLanguage(code string) string {
+	code = strings.ToLower(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return code
+	}
+	for short, long := range languageCodes {
+		for _, c := range long {
+			if c == code {
+				return short
+			}
+		}
+	}
+	return code
+}
+
+// sameLanguage returns true if a and b are codes for the same language,
+// ignoring case and whether they are ISO 639-1 or 639-2 codes.
+func sameLanguage(a, b string) bool {
+	return a != "" && canonicalLanguage(a) == canonicalLanguage(b)
+}
@@ -0,0 +1,31 @@
+package omxplayer
+
+import "testing"
+
+func TestSameLanguage(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"en", "eng", true},
+		{"eng", "en", true},
+		{"EN", "Eng", true},
+		{" en ", "eng", true},
+		{"de", "ger", true},
+		{"de", "deu", true},
+		{"ger", "deu", true},
+		{"fr", "fre", true},
+		{"fra", "fre", true},
+		{"eng", "eng", true},
+		{"xyz", "XYZ", true},
+		{"en", "fre", false},
+		{"en", "e", false},
+		{"", "", false},
+		{"", "en", false},
+	}
+	for _, tt := range tests {
+		if got := sameLanguage(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameLanguage(%q, %q) = %t, want %t", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package omxplayer
+
+// Logger is the interface used to write debug messages. It is satisfied by the
+// loggers of most logging packages, such as logrus.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+}
+
+// nopLogger is a Logger that discards every message.
+type nopLogger struct{}
+
+// Debugf discards the message.
+func (nopLogger) Debugf(format string, args ...interface{}) {}
+
+// logger is the Logger debug messages are written to, unless a player was
+// launched with its own Logger.
+var logger Logger = nopLogger{}
+
+// SetLogger sets the Logger the package writes its debug messages to. By
+// default, debug messages are discarded. Passing nil discards them again.
+// Individual players can use a different Logger through the WithLogger option.
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	logger = l
+}
+
+// debugf writes a debug message to the package's logger.
+func debugf(format string, args ...interface{}) {
+	logger.Debugf(format, args...)
+}
+
+// debugf writes a debug message to the player's logger.
+func (p *Player) debugf(format string, args ...interface{}) {
+	if p.config.logger != nil {
+		p.config.logger.Debugf(format, args...)
+		return
+	}
+	debugf(format, args...)
+}
@@ -0,0 +1,56 @@
+package omxplayer
+
+import (
+	"fmt"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+const (
+	propMetadata = ifaceProps + ".Metadata"
+
+	metadataURL    = "xesam:url"
+	metadataLength = "mpris:length"
+)
+
+// Metadata describes the item the player is playing.
+type Metadata struct {
+	// URI is the file or URL being played, or empty if the player did not
+	// report it.
+	URI string
+
+	// Duration is the length of the item, or zero if the player did not report
+	// it.
+	Duration time.Duration
+
+	// RawFields holds every field the player reported, keyed by its MPRIS
+	// name, including the ones decoded into URI and Duration.
+	RawFields map[string]dbus.Variant
+}
+
+// Metadata returns information about the item the player is playing. Fields
+// that are missing or have an unexpected type are left empty.
+func (p *Player) Metadata() (Metadata, error) {
+	p.debugf("omxplayer: dbus call %s", propMetadata)
+	call := p.call(propMetadata)
+	if call.Err != nil {
+		return Metadata{}, call.Err
+	}
+
+	var fields map[string]dbus.Variant
+	if err := call.Store(&fields); err != nil {
+		return Metadata{}, fmt.Errorf("omxplayer: unexpected reply from %s: %w", propMetadata, err)
+	}
+
+	metadata := Metadata{RawFields: fields}
+	if v, ok := fields[metadataURL]; ok {
+		metadata.URI, _ = v.Value().(string)
+	}
+	if v, ok := fields[metadataLength]; ok {
+		if length, err := float64Body(metadataLength, []interface{}{v}); err == nil {
+			metadata.Duration = fromMicroseconds(int64(length))
+		}
+	}
+	return metadata, nil
+}
@@ -3,12 +3,13 @@
 package omxplayer
 
 import (
+	"context"
 	"os"
 	"os/exec"
 	"strings"
+	"time"
 
 	dbus "github.com/godbus/dbus/v5"
-	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -22,6 +23,8 @@ const (
 	ifaceOmx           = ifaceMpris + ".omxplayer"
 	exeOxmPlayer       = "omxplayer"
 	keyPause           = "p"
+
+	defaultDbusTimeout = 5 * time.Second
 )
 
 var (
@@ -49,14 +52,53 @@ func SetUser(u, h string) {
 // New returns a new Player instance that can be used to control an OMXPlayer
 // instance that is playing the video located at the specified URL.
 func New(url string, args ...string) (player *Player, err error) {
+	return NewWithOptions(url, WithArgs(args...))
+}
+
+// NewWithOptions returns a new Player instance that can be used to control an
+// OMXPlayer instance that is playing the video located at the specified URL.
+// The omxplayer process is launched according to the specified options.
+func NewWithOptions(url string, opts ...Option) (player *Player, err error) {
+	config := &Config{}
+	for _, opt := range opts {
+		if err = opt(config); err != nil {
+			return
+		}
+	}
+	if err = config.finalize(url); err != nil {
+		return
+	}
+
 	removeDbusFiles()
 
-	cmd, err := execOmxplayer(url, args...)
+	args := config.arguments(url)
+	output := newProcessOutput(config)
+	cmd, err := execOmxplayer(config, output, args...)
 	if err != nil {
+		output.close()
 		return
 	}
+	defer func() {
+		if err != nil {
+			cmd.Process.Kill()
+			cmd.Wait()
+			output.close()
+			if config.pidFile != "" {
+				removeFile(config.pidFile)
+			}
+		}
+	}()
+
+	if config.pidFile != "" {
+		if err = writePIDFile(config.pidFile, cmd.Process.Pid); err != nil {
+			return
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.connectTimeout())
+	defer cancel()
 
-	err = setupDbusEnvironment()
+	err = setupDbusEnvironment(ctx)
 	if err != nil {
 		return
 	}
@@ -66,12 +108,31 @@ func New(url string, args ...string) (player *Player, err error) {
 		return
 	}
 
-	bus := conn.Object(ifaceOmx, pathMpris).(*dbus.Object)
+	bus := conn.Object(config.busName(), pathMpris)
 
 	player = &Player{
 		command:    cmd,
 		connection: conn,
 		bus:        bus,
+		url:        url,
+		args:       args,
+		config:     config,
+		output:     output,
+		exited:     make(chan struct{}),
+	}
+
+	if config.dbusTimeout > 0 {
+		if err = player.WaitForReadyContext(ctx); err != nil {
+			conn.Close()
+			player = nil
+			return
+		}
+	}
+
+	go player.reap()
+
+	if config.monitorInterval > 0 {
+		go player.monitorConnection(config.monitorInterval, config.monitorEvents)
 	}
 	return
 }
@@ -87,21 +148,30 @@ func removeDbusFiles() {
 // getDbusPath reads the D-Bus path from the file OMXPlayer writes it's path to.
 // If the file cannot be read, it returns an error, otherwise it returns the
 // path as a string.
-func getDbusPath() (string, error) {
-	if err := waitForFile(fileOmxDbusPath); err != nil {
+func getDbusPath(ctx context.Context) (string, error) {
+	if err := waitForFile(ctx, fileOmxDbusPath); err != nil {
 		return "", err
 	}
-	return readFile(fileOmxDbusPath)
+	return readFile(ctx, fileOmxDbusPath)
 }
 
 // getDbusPath reads the D-Bus PID from the file OMXPlayer writes it's PID to.
 // If the file cannot be read, it returns an error, otherwise it returns the
 // PID as a string.
-func getDbusPid() (string, error) {
-	if err := waitForFile(fileOmxDbusPid); err != nil {
+func getDbusPid(ctx context.Context) (string, error) {
+	if err := waitForFile(ctx, fileOmxDbusPid); err != nil {
 		return "", err
 	}
-	return readFile(fileOmxDbusPid)
+	return readFile(ctx, fileOmxDbusPid)
+}
+
+// dbusAuthMethods returns the methods used to authenticate D-Bus connections as
+// the user set with SetUser.
+func dbusAuthMethods() []dbus.Auth {
+	return []dbus.Auth{
+		dbus.AuthExternal(user),
+		dbus.AuthCookieSha1(user, home),
+	}
 }
 
 // getDbusConnection establishes and returns a D-Bus connection. The connection
@@ -111,38 +181,33 @@ func getDbusPid() (string, error) {
 // `os/user` is not implemented for Linux-ARM, the `authMethods` parameter is
 // specified explicitly rather than passing `nil`.
 func getDbusConnection() (conn *dbus.Conn, err error) {
-	authMethods := []dbus.Auth{
-		dbus.AuthExternal(user),
-		dbus.AuthCookieSha1(user, home),
-	}
-
-	log.Debug("omxplayer: opening dbus session")
+	debugf("omxplayer: opening dbus session")
 	if conn, err = dbus.SessionBusPrivate(); err != nil {
 		return
 	}
 
-	log.Debug("omxplayer: authenticating dbus session")
-	if err = conn.Auth(authMethods); err != nil {
+	debugf("omxplayer: authenticating dbus session")
+	if err = conn.Auth(dbusAuthMethods()); err != nil {
 		return
 	}
 
-	log.Debug("omxplayer: initializing dbus session")
+	debugf("omxplayer: initializing dbus session")
 	err = conn.Hello()
 	return
 }
 
 // setupDbusEnvironment sets the environment variables that are necessary to
-// establish a D-Bus connection. If the connection's path or PID cannot be read,
-// the associated error is returned.
-func setupDbusEnvironment() (err error) {
-	log.Debug("omxplayer: setting up dbus environment")
+// establish a D-Bus connection. If the connection's path or PID cannot be read
+// before ctx expires, the associated error is returned.
+func setupDbusEnvironment(ctx context.Context) (err error) {
+	debugf("omxplayer: setting up dbus environment")
 
-	path, err := getDbusPath()
+	path, err := getDbusPath(ctx)
 	if err != nil {
 		return
 	}
 
-	pid, err := getDbusPid()
+	pid, err := getDbusPid(ctx)
 	if err != nil {
 		return
 	}
@@ -153,15 +218,22 @@ func setupDbusEnvironment() (err error) {
 	return
 }
 
-// execOmxplayer starts a new OMXPlayer process and tells it to pause the video
-// by passing a "p" on standard input.
-func execOmxplayer(url string, args ...string) (cmd *exec.Cmd, err error) {
-	log.Debug("omxplayer: starting omxplayer process")
+// execOmxplayer starts a new OMXPlayer process with the specified configuration
+// and arguments, and tells it to pause the video by passing a "p" on standard
+// input. The output of the process is written to output.
+func execOmxplayer(config *Config, output *processOutput, args ...string) (cmd *exec.Cmd, err error) {
+	debugf("omxplayer: starting omxplayer process: executable=%s args=%q", config.executable, args)
 
-	args = append(args, url)
-
-	cmd = exec.Command(exeOxmPlayer, args...)
+	cmd = exec.Command(config.executable, args...)
 	cmd.Stdin = strings.NewReader(keyPause)
+	cmd.Stdout = output.stdoutWriter()
+	cmd.Stderr = output.stderrWriter()
+	if len(config.env) > 0 {
+		cmd.Env = append(os.Environ(), config.env...)
+	}
+	if config.processGroup {
+		setProcessGroup(cmd)
+	}
 	err = cmd.Start()
 	return
 }
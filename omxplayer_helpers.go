@@ -1,49 +1,60 @@
 package omxplayer
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 	"time"
-
-	log "github.com/sirupsen/logrus"
 )
 
 // removeFile removes the specified file. Errors are ignored.
 func removeFile(path string) {
-	log.WithFields(log.Fields{
-		"path": path,
-	}).Debug("omxplayer: removing file")
+	debugf("omxplayer: removing file: path=%s", path)
 	os.Remove(path)
 }
 
+// removeDir removes the specified directory and everything it contains. Errors
+// are ignored.
+func removeDir(path string) {
+	debugf("omxplayer: removing directory: path=%s", path)
+	os.RemoveAll(path)
+}
+
+// writePIDFile writes the specified PID to the file at the specified path,
+// replacing the file if it already exists.
+func writePIDFile(path string, pid int) error {
+	debugf("omxplayer: writing pid file: path=%s pid=%d", path, pid)
+	return ioutil.WriteFile(path, []byte(strconv.Itoa(pid)+"\n"), 0644)
+}
+
 // waitForFile waits for the specified file to exist before returning. If the an
-// error, other than the file not existing, occurs, the error is returned. If,
-// after 100 attempts, the file does not exist, an error is returned.
-func waitForFile(path string) error {
-	log.WithFields(log.Fields{
-		"file": path,
-	}).Debug("omxplayer: waiting for file")
-	for i := 0; i < 100; i++ {
+// error, other than the file not existing, occurs, the error is returned. If the
+// file still does not exist when ctx expires, an error is returned.
+func waitForFile(ctx context.Context, path string) error {
+	debugf("omxplayer: waiting for file: file=%s", path)
+	for {
 		_, err := os.Stat(path)
 		if err == nil || !os.IsNotExist(err) {
 			return err
 		}
-		time.Sleep(50 * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("omxplayer: file does not exist: %s", path)
+		case <-time.After(50 * time.Millisecond):
+		}
 	}
-	return fmt.Errorf("omxplayer: file does not exist: %s", path)
 }
 
 // readFile waits for the specified file to contain contents, and then returns
 // those contents as a string. If an error occurs while reading the file, the
-// error is returned. If the file has no content after 100 attempts, an error is
-// returned.
-func readFile(path string) (string, error) {
-	log.WithFields(log.Fields{
-		"file": path,
-	}).Debug("omxplayer: reading file")
-	for i := 0; i < 100; i++ {
+// error is returned. If the file still has no content when ctx expires, an
+// error is returned.
+func readFile(ctx context.Context, path string) (string, error) {
+	debugf("omxplayer: reading file: file=%s", path)
+	for {
 		bytes, err := ioutil.ReadFile(path)
 		if err != nil {
 			return "", err
@@ -51,16 +62,16 @@ func readFile(path string) (string, error) {
 		if len(bytes) > 0 {
 			return strings.TrimSpace(string(bytes)), err
 		}
-		time.Sleep(50 * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("omxplayer: file is empty: %s", path)
+		case <-time.After(50 * time.Millisecond):
+		}
 	}
-	return "", fmt.Errorf("omxplayer: file is empty: %s", path)
 }
 
 // setEnv sets the specified environment variable to the specified value.
 func setEnv(variable, value string) {
-	log.WithFields(log.Fields{
-		"variable": variable,
-		"value":    value,
-	}).Debug("omxplayer: setting environment variable")
+	debugf("omxplayer: setting environment variable: variable=%s value=%s", variable, value)
 	os.Setenv(variable, value)
 }
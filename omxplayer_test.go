@@ -0,0 +1,43 @@
+package omxplayer
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNewRemovesPIDFileOnError(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep is not available")
+	}
+
+	dir, err := ioutil.TempDir("", "omxplayer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Look for omxplayer's D-Bus files in dir, where they are never written,
+	// so that New times out waiting for them.
+	SetUser("omxplayer-test", dir)
+	defer SetUser(os.Getenv("USER"), os.Getenv("HOME"))
+	fileOmxDbusPath = filepath.Join(dir, "dbus")
+	fileOmxDbusPid = filepath.Join(dir, "dbus.pid")
+
+	pidFile := filepath.Join(dir, "omxplayer.pid")
+	_, err = NewWithOptions("10",
+		WithExecutable("sleep"),
+		WithSkipSourceCheck(),
+		WithPIDFile(pidFile),
+		WithDBusTimeout(100*time.Millisecond),
+	)
+	if err == nil {
+		t.Fatal("New succeeded without omxplayer's D-Bus files")
+	}
+	if _, statErr := os.Stat(pidFile); !os.IsNotExist(statErr) {
+		t.Errorf("PID file was left behind after New failed: %v", statErr)
+	}
+}
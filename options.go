@@ -0,0 +1,987 @@
+package omxplayer
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// liveThreshold is the amount of buffered data, in seconds, omxplayer waits for
+// before playing a live source, unless another threshold is set.
+const liveThreshold = "0.1"
+
+// keyDeinterlace identifies the deinterlacing flags, which are mutually exclusive
+// despite having different names.
+const keyDeinterlace = "deinterlace"
+
+// keyBlank identifies the -b and --blank flags, which are two forms of the same
+// flag.
+const keyBlank = "blank"
+
+// AudioOutput identifies the device omxplayer plays audio through.
+type AudioOutput string
+
+// The audio outputs supported by omxplayer. Use AudioALSA to play audio through
+// a specific ALSA device.
+const (
+	AudioHDMI  AudioOutput = "hdmi"
+	AudioLocal AudioOutput = "local"
+	AudioBoth  AudioOutput = "both"
+
+	prefixAudioALSA = "alsa:"
+)
+
+// AudioALSA returns the AudioOutput that plays audio through the ALSA device
+// with the specified name, such as "hw:1,0".
+func AudioALSA(device string) AudioOutput {
+	return AudioOutput(prefixAudioALSA + device)
+}
+
+// validate returns an error if o is not an audio output omxplayer supports.
+func (o AudioOutput) validate() error {
+	switch o {
+	case AudioHDMI, AudioLocal, AudioBoth:
+		return nil
+	}
+	if strings.HasPrefix(string(o), prefixAudioALSA) && len(o) > len(prefixAudioALSA) {
+		return nil
+	}
+	return fmt.Errorf("omxplayer: invalid audio output: %q", o)
+}
+
+// SubtitleAlign identifies how omxplayer aligns subtitles horizontally.
+type SubtitleAlign string
+
+// The subtitle alignments supported by omxplayer.
+const (
+	SubtitleAlignLeft   SubtitleAlign = "left"
+	SubtitleAlignCenter SubtitleAlign = "center"
+)
+
+// nonSeekableSchemes lists the URL schemes of live streams that cannot be
+// seeked in.
+var nonSeekableSchemes = []string{"rtsp", "rtmp", "rtp", "udp", "mms", "mmsh"}
+
+// seekable returns false if url is known to refer to a source that cannot be
+// seeked in, such as a live stream or a named pipe.
+func seekable(url string) bool {
+	for _, scheme := range nonSeekableSchemes {
+		if strings.HasPrefix(strings.ToLower(url), scheme+"://") {
+			return false
+		}
+	}
+	if info, err := os.Stat(url); err == nil && info.Mode()&os.ModeNamedPipe != 0 {
+		return false
+	}
+	return true
+}
+
+// Mode3D identifies the layout of stereoscopic 3D video.
+type Mode3D string
+
+// The 3D modes supported by omxplayer: side by side, top and bottom, and frame
+// packing.
+const (
+	Mode3DSBS Mode3D = "SBS"
+	Mode3DTB  Mode3D = "TB"
+	Mode3DFP  Mode3D = "FP"
+)
+
+// DeinterlaceMode controls how omxplayer deinterlaces video.
+type DeinterlaceMode int
+
+// The deinterlacing modes supported by omxplayer. DeinterlaceAuto leaves the
+// decision to omxplayer, DeinterlaceForce always deinterlaces, DeinterlaceNative
+// hands interlaced frames to the display as they are, and DeinterlaceOff never
+// deinterlaces.
+const (
+	DeinterlaceAuto DeinterlaceMode = iota
+	DeinterlaceForce
+	DeinterlaceNative
+	DeinterlaceOff
+)
+
+// deinterlaceFlags maps each deinterlacing mode to the flag that selects it.
+var deinterlaceFlags = map[DeinterlaceMode]string{
+	DeinterlaceForce:  "--deinterlace",
+	DeinterlaceNative: "--nativedeinterlace",
+	DeinterlaceOff:    "--nodeinterlace",
+}
+
+// The dispmanx display IDs accepted by WithDisplay. On a Raspberry Pi 4, the two
+// HDMI ports are DisplayHDMI0 and DisplayHDMI1.
+const (
+	DisplayMainLCD    = 0
+	DisplayAuxLCD     = 1
+	DisplayHDMI0      = 2
+	DisplaySDTV       = 3
+	DisplayForceLCD   = 4
+	DisplayForceTV    = 5
+	DisplayForceOther = 6
+	DisplayHDMI1      = 7
+)
+
+// Option configures how a new omxplayer process is launched. Options are passed
+// to NewWithOptions and applied in order. When several options set the same
+// flag, the last one wins.
+type Option func(*Config) error
+
+// Config holds the launch settings assembled from the Options passed to
+// NewWithOptions.
+type Config struct {
+	flags       []flag
+	args        []string
+	pidFile     string
+	audioOutput AudioOutput
+	window      *Rect
+	aspectMode  AspectMode
+	orientation int
+	layer       int
+	alpha       *uint8
+	display     int
+	loop        bool
+	live        bool
+	avdict      map[string]string
+
+	passthrough   bool
+	hwAudioDecode bool
+
+	processGroup    bool
+	executable      string
+	skipSourceCheck bool
+	env             []string
+	stdout          io.Writer
+	stderr          io.Writer
+
+	logger      Logger
+	dbusTimeout time.Duration
+	dbusName    string
+	dbusAddress string
+
+	autoReconnect bool
+
+	chapterDelay time.Duration
+	volumeFloor  float64
+	fadeInterval time.Duration
+
+	monitorInterval time.Duration
+	monitorEvents   chan<- error
+}
+
+// flag is a command line flag along with its values. Flags are identified by a
+// key so that setting the same flag again replaces the earlier value.
+type flag struct {
+	key  string
+	args []string
+}
+
+// setFlag sets the flag identified by key to the specified arguments, replacing
+// any earlier value while keeping its position in the argument list.
+func (c *Config) setFlag(key string, args ...string) {
+	for i := range c.flags {
+		if c.flags[i].key == key {
+			c.flags[i].args = args
+			return
+		}
+	}
+	c.flags = append(c.flags, flag{key: key, args: args})
+}
+
+// finalize checks that the settings can be used to play the specified URL and
+// fills in the defaults that depend on other settings.
+func (c *Config) finalize(url string) error {
+	executable := c.executable
+	if executable == "" {
+		executable = exeOxmPlayer
+	}
+	path, err := exec.LookPath(executable)
+	if err != nil {
+		return &wrappedError{ErrExecutableNotFound, err}
+	}
+	c.executable = path
+
+	if !c.skipSourceCheck {
+		if err = checkSource(url); err != nil {
+			return err
+		}
+	}
+	if c.loop && (c.live || !seekable(url)) {
+		return fmt.Errorf("omxplayer: cannot loop a source that is not seekable: %s", url)
+	}
+	if c.live && !c.hasFlag("--threshold") {
+		c.setFlag("--threshold", "--threshold", liveThreshold)
+	}
+	if len(c.avdict) > 0 {
+		c.setFlag("--avdict", "--avdict", formatAVDict(c.avdict))
+	}
+	return nil
+}
+
+// connectTimeout returns how long to wait for omxplayer's D-Bus interface to
+// become available.
+func (c *Config) connectTimeout() time.Duration {
+	if c.dbusTimeout != 0 {
+		return c.dbusTimeout
+	}
+	return defaultDbusTimeout
+}
+
+// busName returns the D-Bus name omxplayer registers.
+func (c *Config) busName() string {
+	if c.dbusName != "" {
+		return c.dbusName
+	}
+	return ifaceOmx
+}
+
+// hasFlag returns true if the flag identified by key is set.
+func (c *Config) hasFlag(key string) bool {
+	for _, f := range c.flags {
+		if f.key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// unsetFlag removes the flag identified by key, if it is set.
+func (c *Config) unsetFlag(key string) {
+	for i := range c.flags {
+		if c.flags[i].key == key {
+			c.flags = append(c.flags[:i], c.flags[i+1:]...)
+			return
+		}
+	}
+}
+
+// arguments assembles the complete omxplayer argument list for the specified
+// URL: the flags in the order they were first set, followed by the raw
+// arguments and the URL itself.
+func (c *Config) arguments(url string) []string {
+	var args []string
+	for _, f := range c.flags {
+		args = append(args, f.args...)
+	}
+	args = append(args, c.args...)
+	return append(args, url)
+}
+
+// WithFlag sets an omxplayer command line flag, such as "--no-osd", along with
+// any values it takes. Setting the same flag again replaces the earlier values.
+func WithFlag(name string, values ...string) Option {
+	return func(c *Config) error {
+		if !strings.HasPrefix(name, "-") {
+			return fmt.Errorf("omxplayer: invalid flag: %q", name)
+		}
+		c.setFlag(name, append([]string{name}, values...)...)
+		return nil
+	}
+}
+
+// WithArgs passes the specified command line arguments to the omxplayer
+// process verbatim, after all of the flags set by other options. It is an
+// escape hatch for arguments that no other option covers; no validation or
+// deduplication is performed.
+func WithArgs(args ...string) Option {
+	return func(c *Config) error {
+		c.args = append(c.args, args...)
+		return nil
+	}
+}
+
+// WithPIDFile writes the PID of the omxplayer process to the specified file once
+// the process has started. An existing file, such as one left behind by a
+// previous run that crashed, is overwritten. The file is removed when the
+// player is quit or the process exits.
+func WithPIDFile(path string) Option {
+	return func(c *Config) error {
+		c.pidFile = path
+		return nil
+	}
+}
+
+// WithAudioOutput selects the device omxplayer plays audio through, using the
+// -o flag. An error is returned if the output is not supported, such as an ALSA
+// output without a device name.
+func WithAudioOutput(output AudioOutput) Option {
+	return func(c *Config) error {
+		if err := output.validate(); err != nil {
+			return err
+		}
+		c.setFlag("-o", "-o", string(output))
+		c.audioOutput = output
+		return nil
+	}
+}
+
+// WithConnectionMonitor checks the health of the D-Bus connection at the
+// specified interval while the omxplayer process is running. If the connection
+// dies, it is transparently re-established and the result of the attempt, nil
+// on success, is sent through events. Events that cannot be delivered straight
+// away are dropped. Pass a nil channel to recover without being notified.
+func WithConnectionMonitor(interval time.Duration, events chan<- error) Option {
+	return func(c *Config) error {
+		if interval <= 0 {
+			return fmt.Errorf("omxplayer: invalid connection monitor interval: %s", interval)
+		}
+		c.monitorInterval = interval
+		c.monitorEvents = events
+		return nil
+	}
+}
+
+// WithWindow plays the video in the rectangle with the specified corners rather
+// than fullscreen, using the --win flag. An error wrapping ErrInvalidRect is
+// returned if the coordinates are negative or do not describe a rectangle.
+func WithWindow(x1, y1, x2, y2 int) Option {
+	return func(c *Config) error {
+		window := Rect{X1: x1, Y1: y1, X2: x2, Y2: y2}
+		if err := window.validate(); err != nil {
+			return err
+		}
+		c.setFlag("--win", "--win", fmt.Sprintf("%d,%d,%d,%d", x1, y1, x2, y2))
+		c.window = &window
+		return nil
+	}
+}
+
+// WithLoop makes omxplayer loop the video, using the --loop flag. omxplayer can
+// only loop seekable sources, so New returns an error if the source is known not
+// to be seekable, such as a live stream or a reader passed to NewFromReader.
+// Looping does not prevent the player from being stopped: Quit and Stop still
+// terminate the omxplayer process.
+func WithLoop() Option {
+	return func(c *Config) error {
+		c.setFlag("--loop", "--loop")
+		c.loop = true
+		return nil
+	}
+}
+
+// WithProcessGroup launches omxplayer in a new process group, so that Kill and
+// Close terminate any helper processes it spawned along with it instead of
+// leaving them orphaned. Process groups are a Unix concept; on other platforms
+// this option returns an error. Leave it unset if you manage process groups
+// yourself.
+func WithProcessGroup() Option {
+	return func(c *Config) error {
+		if !processGroupSupported {
+			return fmt.Errorf("omxplayer: process groups are not supported on this platform")
+		}
+		c.processGroup = true
+		return nil
+	}
+}
+
+// WithInitialVolume starts omxplayer at the specified volume in millibels, using
+// the --vol flag, so that playback does not start at full volume before the
+// Volume method can be called. Use VolumeToMillibels to convert a volume from
+// the linear scale used by the Volume method. An error is returned if the volume
+// is not between -6000 and 0 millibels.
+func WithInitialVolume(millibels int) Option {
+	return func(c *Config) error {
+		if millibels < minVolumeMillibels || millibels > maxVolumeMillibels {
+			return fmt.Errorf("omxplayer: initial volume must be between %d and %d millibels: %d",
+				minVolumeMillibels, maxVolumeMillibels, millibels)
+		}
+		c.setFlag("--vol", "--vol", strconv.Itoa(millibels))
+		return nil
+	}
+}
+
+// WithStartPosition starts playback at the specified position, using the --pos
+// flag, rather than seeking after the player has started. The position is kept
+// to the millisecond. An error is returned if it is negative.
+func WithStartPosition(d time.Duration) Option {
+	return func(c *Config) error {
+		if d < 0 {
+			return fmt.Errorf("omxplayer: invalid start position: %s", d)
+		}
+		c.setFlag("--pos", "--pos", formatPosition(d))
+		return nil
+	}
+}
+
+// formatPosition formats a position for the --pos flag. Whole seconds are
+// formatted as hh:mm:ss. omxplayer ignores fractions of a second in that form,
+// so other positions are formatted as a number of seconds with millisecond
+// precision, such as 3723.250, which omxplayer also accepts.
+func formatPosition(d time.Duration) string {
+	if d%time.Second != 0 {
+		return fmt.Sprintf("%.3f", d.Truncate(time.Millisecond).Seconds())
+	}
+	seconds := int64(d / time.Second)
+	return fmt.Sprintf("%02d:%02d:%02d", seconds/3600, seconds/60%60, seconds%60)
+}
+
+// WithAspectMode sets how omxplayer fits the video to the screen from the very
+// first frame, using the --aspect-mode flag. An error is returned if the mode is
+// not supported.
+func WithAspectMode(mode AspectMode) Option {
+	return func(c *Config) error {
+		if err := mode.validate(); err != nil {
+			return err
+		}
+		c.setFlag("--aspect-mode", "--aspect-mode", string(mode))
+		c.aspectMode = mode
+		return nil
+	}
+}
+
+// WithOrientation rotates the video by the specified number of degrees, using
+// the --orientation flag, for example to drive a portrait-mounted screen. An
+// error is returned unless degrees is 0, 90, 180 or 270.
+func WithOrientation(degrees int) Option {
+	return func(c *Config) error {
+		switch degrees {
+		case 0, 90, 180, 270:
+		default:
+			return fmt.Errorf("omxplayer: invalid orientation: %d", degrees)
+		}
+		c.setFlag("--orientation", "--orientation", strconv.Itoa(degrees))
+		c.orientation = degrees
+		return nil
+	}
+}
+
+// WithLogger writes the player's debug messages to the specified logger instead
+// of the package's default logger.
+func WithLogger(logger Logger) Option {
+	return func(c *Config) error {
+		c.logger = logger
+		return nil
+	}
+}
+
+// WithDBusTimeout limits how long New waits for omxplayer's D-Bus interface to
+// become available. When set, New also waits for the player to become ready,
+// so the returned Player can be used straight away. If the interface is not
+// available in time, the omxplayer process is killed and an error is returned.
+func WithDBusTimeout(timeout time.Duration) Option {
+	return func(c *Config) error {
+		if timeout <= 0 {
+			return fmt.Errorf("omxplayer: invalid dbus timeout: %s", timeout)
+		}
+		c.dbusTimeout = timeout
+		return nil
+	}
+}
+
+// WithLayer sets the dispmanx layer the video is displayed on, using the --layer
+// flag. Videos on higher layers are displayed on top of those on lower layers,
+// which allows several players to be stacked. Negative layers are valid.
+func WithLayer(layer int) Option {
+	return func(c *Config) error {
+		c.setFlag("--layer", "--layer", strconv.Itoa(layer))
+		c.layer = layer
+		return nil
+	}
+}
+
+// WithAlpha sets the opacity of the video, from 0 for fully transparent to 255
+// for fully opaque, using the --alpha flag. Combined with WithLayer, this allows
+// the video to be composited over whatever is displayed on lower layers without
+// an initial fully opaque frame.
+func WithAlpha(alpha uint8) Option {
+	return func(c *Config) error {
+		c.setFlag("--alpha", "--alpha", strconv.Itoa(int(alpha)))
+		c.alpha = &alpha
+		return nil
+	}
+}
+
+// WithDisplay selects the display the video is played on, using the --display
+// flag. An error is returned unless the display is one of the dispmanx display
+// IDs, DisplayMainLCD to DisplayHDMI1. For unusual setups that use other IDs,
+// pass the flag through WithFlag instead.
+func WithDisplay(display int) Option {
+	return func(c *Config) error {
+		if display < DisplayMainLCD || display > DisplayHDMI1 {
+			return fmt.Errorf("omxplayer: unknown display %d, expected a dispmanx display id between %d and %d",
+				display, DisplayMainLCD, DisplayHDMI1)
+		}
+		c.setFlag("--display", "--display", strconv.Itoa(display))
+		c.display = display
+		return nil
+	}
+}
+
+// WithMode3D plays stereoscopic 3D video with the specified layout, using the
+// --3d flag. An error is returned if the mode is not supported.
+func WithMode3D(mode Mode3D) Option {
+	return func(c *Config) error {
+		switch mode {
+		case Mode3DSBS, Mode3DTB, Mode3DFP:
+		default:
+			return fmt.Errorf("omxplayer: invalid 3d mode: %q", mode)
+		}
+		c.setFlag("--3d", "--3d", string(mode))
+		return nil
+	}
+}
+
+// WithPassthrough passes the audio stream through to the receiver undecoded,
+// using the -p flag, so that a receiver can decode formats such as AC3 and DTS
+// itself. Since omxplayer no longer decodes the audio, its volume cannot be
+// changed; Volume returns ErrPassthrough when asked to.
+func WithPassthrough() Option {
+	return func(c *Config) error {
+		c.setFlag("-p", "-p")
+		c.passthrough = true
+		return nil
+	}
+}
+
+// WithHWAudioDecode decodes audio in hardware, using the --hw flag.
+func WithHWAudioDecode() Option {
+	return func(c *Config) error {
+		c.setFlag("--hw", "--hw")
+		c.hwAudioDecode = true
+		return nil
+	}
+}
+
+// WithDeinterlace selects how omxplayer deinterlaces video, using the
+// --deinterlace, --nativedeinterlace or --nodeinterlace flag. Since only one
+// mode can be selected, the modes cannot conflict. DeinterlaceAuto passes no
+// flag at all. An error is returned if the mode is not supported.
+func WithDeinterlace(mode DeinterlaceMode) Option {
+	return func(c *Config) error {
+		if mode == DeinterlaceAuto {
+			c.unsetFlag(keyDeinterlace)
+			return nil
+		}
+
+		name, ok := deinterlaceFlags[mode]
+		if !ok {
+			return fmt.Errorf("omxplayer: invalid deinterlace mode: %d", mode)
+		}
+		c.setFlag(keyDeinterlace, name)
+		return nil
+	}
+}
+
+// WithLive tells omxplayer that the source is a live stream, such as an RTSP
+// camera feed, using the --live flag. This keeps audio and video from drifting
+// apart. Unless a threshold is set through another option, the buffering
+// threshold is also lowered to reduce latency. Live sources cannot be looped.
+func WithLive() Option {
+	return func(c *Config) error {
+		c.setFlag("--live", "--live")
+		c.live = true
+		return nil
+	}
+}
+
+// WithAVDict passes options to libavformat, the library omxplayer uses to open
+// its source, using the --avdict flag. Options from several calls are merged,
+// with later values replacing earlier ones for the same key. Keys are sorted so
+// that the resulting argument is deterministic, and commas, colons and
+// backslashes in keys and values are escaped. An empty map adds nothing.
+func WithAVDict(opts map[string]string) Option {
+	return func(c *Config) error {
+		for key, value := range opts {
+			if key == "" {
+				return fmt.Errorf("omxplayer: empty avdict key")
+			}
+			if c.avdict == nil {
+				c.avdict = make(map[string]string)
+			}
+			c.avdict[key] = value
+		}
+		return nil
+	}
+}
+
+// WithRTSPTransportTCP makes libavformat receive RTSP streams over TCP rather
+// than UDP, which avoids artifacts caused by lost packets on IP cameras.
+func WithRTSPTransportTCP() Option {
+	return WithAVDict(map[string]string{"rtsp_transport": "tcp"})
+}
+
+// avdictEscaper escapes the characters libavformat treats specially when
+// parsing the --avdict flag.
+var avdictEscaper = strings.NewReplacer(`\`, `\\`, ":", `\:`, ",", `\,`)
+
+// formatAVDict formats libavformat options as key:value pairs separated by
+// commas, sorted by key.
+func formatAVDict(opts map[string]string) string {
+	keys := make([]string, 0, len(opts))
+	for key := range opts {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = avdictEscaper.Replace(key) + ":" + avdictEscaper.Replace(opts[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// WithUserAgent sets the User-Agent header omxplayer sends when opening HTTP
+// sources, using the --user-agent flag. The value is passed to omxplayer as a
+// single argument rather than through a shell, so spaces and semicolons need no
+// quoting. An error is returned if the value is empty.
+func WithUserAgent(userAgent string) Option {
+	return func(c *Config) error {
+		if userAgent == "" {
+			return fmt.Errorf("omxplayer: empty user agent")
+		}
+		c.setFlag("--user-agent", "--user-agent", userAgent)
+		return nil
+	}
+}
+
+// WithCookie sets the cookies omxplayer sends when opening HTTP sources, using
+// the --cookie flag, for example "session=abc; token=def". Like WithUserAgent,
+// the value is passed as is, and an error is returned if it is empty.
+func WithCookie(cookie string) Option {
+	return func(c *Config) error {
+		if cookie == "" {
+			return fmt.Errorf("omxplayer: empty cookie")
+		}
+		c.setFlag("--cookie", "--cookie", cookie)
+		return nil
+	}
+}
+
+// WithThreshold sets how many seconds of data omxplayer buffers before it
+// starts playing, using the --threshold flag. An error is returned unless
+// seconds is positive.
+func WithThreshold(seconds float64) Option {
+	return positiveFloatFlag("--threshold", "threshold", seconds)
+}
+
+// WithVideoQueue sets the size of the video packet queue in megabytes, using the
+// --video_queue flag. An error is returned unless mb is positive.
+func WithVideoQueue(mb float64) Option {
+	return positiveFloatFlag("--video_queue", "video queue size", mb)
+}
+
+// WithAudioQueue sets the size of the audio packet queue in megabytes, using the
+// --audio_queue flag. An error is returned unless mb is positive.
+func WithAudioQueue(mb float64) Option {
+	return positiveFloatFlag("--audio_queue", "audio queue size", mb)
+}
+
+// WithVideoFifo sets the size of the video output FIFO in megabytes, using the
+// --video_fifo flag. An error is returned unless mb is positive.
+func WithVideoFifo(mb float64) Option {
+	return positiveFloatFlag("--video_fifo", "video fifo size", mb)
+}
+
+// WithAudioFifo sets the size of the audio output FIFO in megabytes, using the
+// --audio_fifo flag. An error is returned unless mb is positive.
+func WithAudioFifo(mb float64) Option {
+	return positiveFloatFlag("--audio_fifo", "audio fifo size", mb)
+}
+
+// WithNetworkTuning enlarges omxplayer's buffers for high bitrate sources read
+// over the network, such as NFS shares or HTTP streams, which otherwise tend to
+// stutter. It is equivalent to passing --threshold 5 --video_queue 4
+// --audio_queue 4 --video_fifo 2. Options applied after it override the
+// individual values.
+func WithNetworkTuning() Option {
+	return func(c *Config) error {
+		for _, opt := range []Option{
+			WithThreshold(5),
+			WithVideoQueue(4),
+			WithAudioQueue(4),
+			WithVideoFifo(2),
+		} {
+			if err := opt(c); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// positiveFloatFlag returns an option that sets flag to value, returning an
+// error that mentions name unless value is positive.
+func positiveFloatFlag(flag, name string, value float64) Option {
+	return func(c *Config) error {
+		if !(value > 0) {
+			return fmt.Errorf("omxplayer: %s must be positive: %v", name, value)
+		}
+		c.setFlag(flag, flag, strconv.FormatFloat(value, 'f', -1, 64))
+		return nil
+	}
+}
+
+// WithSubtitles displays the subtitles in the external file at the specified
+// path, such as an .srt file, using the --subtitles flag. An error wrapping
+// ErrSubtitlesNotFound is returned if the file does not exist, and an error is
+// also returned if it cannot be read.
+func WithSubtitles(path string) Option {
+	return func(c *Config) error {
+		if err := checkReadable(path); err != nil {
+			if os.IsNotExist(err) {
+				return &wrappedError{ErrSubtitlesNotFound, err}
+			}
+			return fmt.Errorf("omxplayer: cannot read subtitle file: %w", err)
+		}
+		c.setFlag("--subtitles", "--subtitles", path)
+		return nil
+	}
+}
+
+// checkReadable returns an error if the file at the specified path does not
+// exist, is a directory or cannot be opened for reading.
+func checkReadable(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if info.IsDir() {
+		return fmt.Errorf("%s is a directory", path)
+	}
+	return nil
+}
+
+// WithSubtitleFontSize sets the size of the subtitle font, using the
+// --font-size flag. omxplayer measures it in thousandths of the screen height.
+// An error is returned unless size is positive. Like the other subtitle styling
+// options, it also applies to subtitles embedded in the video.
+func WithSubtitleFontSize(size int) Option {
+	return func(c *Config) error {
+		if size <= 0 {
+			return fmt.Errorf("omxplayer: subtitle font size must be positive: %d", size)
+		}
+		c.setFlag("--font-size", "--font-size", strconv.Itoa(size))
+		return nil
+	}
+}
+
+// WithSubtitleAlign sets the horizontal alignment of subtitles, using the
+// --align flag. An error is returned if the alignment is not supported.
+func WithSubtitleAlign(align SubtitleAlign) Option {
+	return func(c *Config) error {
+		switch align {
+		case SubtitleAlignLeft, SubtitleAlignCenter:
+		default:
+			return fmt.Errorf("omxplayer: invalid subtitle alignment: %q", align)
+		}
+		c.setFlag("--align", "--align", string(align))
+		return nil
+	}
+}
+
+// WithSubtitleLines sets the number of lines reserved for subtitles, using the
+// --lines flag. An error is returned unless lines is positive.
+func WithSubtitleLines(lines int) Option {
+	return func(c *Config) error {
+		if lines <= 0 {
+			return fmt.Errorf("omxplayer: subtitle lines must be positive: %d", lines)
+		}
+		c.setFlag("--lines", "--lines", strconv.Itoa(lines))
+		return nil
+	}
+}
+
+// WithNoGhostBox stops omxplayer from drawing a translucent box behind
+// subtitles, using the --no-ghost-box flag.
+func WithNoGhostBox() Option {
+	return func(c *Config) error {
+		c.setFlag("--no-ghost-box", "--no-ghost-box")
+		return nil
+	}
+}
+
+// WithNoOSD stops omxplayer from displaying its on-screen display, such as the
+// seek bar shown when the position changes, using the --no-osd flag.
+func WithNoOSD() Option {
+	return func(c *Config) error {
+		c.setFlag("--no-osd", "--no-osd")
+		return nil
+	}
+}
+
+// WithNoKeys stops omxplayer from reading keyboard input, using the --no-keys
+// flag, so that a keyboard attached to the device cannot control playback. The
+// flag only disables the keyboard: the Action method and the other D-Bus
+// methods keep working.
+func WithNoKeys() Option {
+	return func(c *Config) error {
+		c.setFlag("--no-keys", "--no-keys")
+		return nil
+	}
+}
+
+// WithBlankBackground clears the screen behind the video to black, using the -b
+// flag, so that the console is not visible before the video starts or around
+// it. Only the last of WithBlankBackground and WithBackgroundColor applies.
+func WithBlankBackground() Option {
+	return func(c *Config) error {
+		c.setFlag(keyBlank, "-b")
+		return nil
+	}
+}
+
+// WithBackgroundColor clears the screen behind the video to the specified
+// colour, given as 0xAARRGGBB, using the --blank flag. Only the last of
+// WithBlankBackground and WithBackgroundColor applies.
+func WithBackgroundColor(argb uint32) Option {
+	return func(c *Config) error {
+		c.setFlag(keyBlank, fmt.Sprintf("--blank=0x%08X", argb))
+		return nil
+	}
+}
+
+// WithAmplification applies the specified gain, in millibels, to the decoded
+// audio, using the --amp flag, for example to boost quiet recordings above full
+// volume. Unlike WithInitialVolume, which sets the player's volume and can only
+// attenuate, amplification is applied on top of the volume, so the two combine:
+// an amplification of 600 with an initial volume of -600 plays at the original
+// level. An error is returned if the gain is not between -6000 and 6000
+// millibels.
+func WithAmplification(millibels int) Option {
+	return func(c *Config) error {
+		if millibels < minAmplificationMillibels || millibels > maxAmplificationMillibels {
+			return fmt.Errorf("omxplayer: amplification must be between %d and %d millibels: %d",
+				minAmplificationMillibels, maxAmplificationMillibels, millibels)
+		}
+		c.setFlag("--amp", "--amp", strconv.Itoa(millibels))
+		return nil
+	}
+}
+
+// WithExecutable runs the omxplayer executable at the specified path, or with
+// the specified name looked up in PATH, instead of "omxplayer". New returns an
+// error wrapping ErrExecutableNotFound if it does not exist or is not
+// executable.
+func WithExecutable(path string) Option {
+	return func(c *Config) error {
+		if path == "" {
+			return fmt.Errorf("omxplayer: empty executable path")
+		}
+		c.executable = path
+		return nil
+	}
+}
+
+// WithEnv sets additional environment variables, in the form "KEY=value", for
+// the omxplayer process, for example LD_LIBRARY_PATH for an installation in a
+// non-standard prefix. The process otherwise inherits the environment of the
+// current process, and variables from several calls are combined.
+func WithEnv(vars []string) Option {
+	return func(c *Config) error {
+		for _, v := range vars {
+			if !strings.Contains(v, "=") {
+				return fmt.Errorf("omxplayer: invalid environment variable: %q", v)
+			}
+		}
+		c.env = append(c.env, vars...)
+		return nil
+	}
+}
+
+// WithStdout writes the standard output of the omxplayer process to w, which is
+// discarded otherwise. Output is passed to w from a separate goroutine, so a
+// slow writer cannot stall the process, but output that w cannot keep up with
+// is dropped.
+func WithStdout(w io.Writer) Option {
+	return func(c *Config) error {
+		c.stdout = w
+		return nil
+	}
+}
+
+// WithStderr writes the standard error of the omxplayer process to w, in the
+// same way WithStdout does for its standard output. The last few kilobytes are
+// also available from LastOutput.
+func WithStderr(w io.Writer) Option {
+	return func(c *Config) error {
+		c.stderr = w
+		return nil
+	}
+}
+
+// WithSkipSourceCheck stops New from checking that the file to be played exists
+// and that the scheme of the URL to be played is supported, for sources the
+// check does not understand, such as devices or protocols omxplayer was built
+// with specially.
+func WithSkipSourceCheck() Option {
+	return func(c *Config) error {
+		c.skipSourceCheck = true
+		return nil
+	}
+}
+
+// WithChapterDelay sets how long SeekChapter waits between skipping chapters,
+// which gives omxplayer time to complete each skip. The default is 100ms.
+func WithChapterDelay(delay time.Duration) Option {
+	return func(c *Config) error {
+		if delay <= 0 {
+			return fmt.Errorf("omxplayer: invalid chapter delay: %s", delay)
+		}
+		c.chapterDelay = delay
+		return nil
+	}
+}
+
+// WithVolumeFloor sets the quietest volume, in decibels relative to full
+// volume, that SetVolumeDB sets and VolumeDB reports; quieter volumes are
+// treated as silence. The default is MinVolumeDB. An error is returned unless
+// db is negative.
+func WithVolumeFloor(db float64) Option {
+	return func(c *Config) error {
+		if !(db < 0) || math.IsInf(db, -1) {
+			return fmt.Errorf("omxplayer: volume floor must be a negative number of decibels: %v", db)
+		}
+		c.volumeFloor = db
+		return nil
+	}
+}
+
+// WithFadeInterval sets how often FadeVolume adjusts the volume. Shorter
+// intervals give smoother fades at the cost of more D-Bus calls. The default is
+// 50ms.
+func WithFadeInterval(interval time.Duration) Option {
+	return func(c *Config) error {
+		if interval <= 0 {
+			return fmt.Errorf("omxplayer: invalid fade interval: %s", interval)
+		}
+		c.fadeInterval = interval
+		return nil
+	}
+}
+
+// WithDBusName makes omxplayer register the specified D-Bus name instead of
+// org.mpris.MediaPlayer2.omxplayer, using the --dbus_name flag, and controls it
+// through that name. This allows several players to run at the same time, for
+// example to play videos in different windows; every running player must use a
+// different name.
+func WithDBusName(name string) Option {
+	return func(c *Config) error {
+		if name == "" {
+			return fmt.Errorf("omxplayer: empty dbus name")
+		}
+		c.setFlag("--dbus_name", "--dbus_name", name)
+		c.dbusName = name
+		return nil
+	}
+}
+
+// WithAutoReconnect makes the player re-establish its D-Bus connection when a
+// call fails because the connection to omxplayer was lost after the player had
+// become ready, and then retry the call once. See Player.Reconnect.
+func WithAutoReconnect() Option {
+	return func(c *Config) error {
+		c.autoReconnect = true
+		return nil
+	}
+}
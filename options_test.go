@@ -0,0 +1,510 @@
+package omxplayer
+
+import (
+	"errors"
+	"io/ioutil"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// launchArguments applies opts to a new Config like New does and returns the
+// arguments omxplayer would be launched with to play url. The test binary
+// stands in for the omxplayer executable and the source check is skipped, so
+// that the arguments do not depend on what is installed.
+func launchArguments(url string, opts ...Option) ([]string, error) {
+	config := &Config{executable: os.Args[0], skipSourceCheck: true}
+	for _, opt := range opts {
+		if err := opt(config); err != nil {
+			return nil, err
+		}
+	}
+	if err := config.finalize(url); err != nil {
+		return nil, err
+	}
+	return config.arguments(url), nil
+}
+
+// argumentTest is a test case for the arguments produced by a set of options,
+// not counting the URL that follows them.
+type argumentTest struct {
+	name string
+	opts []Option
+	want []string
+}
+
+// testArguments checks the arguments each test case produces for test.mp4.
+func testArguments(t *testing.T, tests []argumentTest) {
+	t.Helper()
+	testURLArguments(t, "test.mp4", tests)
+}
+
+// testURLArguments checks the arguments each test case produces for url.
+func testURLArguments(t *testing.T, url string, tests []argumentTest) {
+	t.Helper()
+	for _, tt := range tests {
+		got, err := launchArguments(url, tt.opts...)
+		if err != nil {
+			t.Errorf("%s: returned error: %v", tt.name, err)
+			continue
+		}
+		want := append(append([]string(nil), tt.want...), url)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got arguments %q, want %q", tt.name, got, want)
+		}
+	}
+}
+
+// testInvalidOptions checks that applying each option returns an error.
+func testInvalidOptions(t *testing.T, opts map[string]Option) {
+	t.Helper()
+	for name, opt := range opts {
+		if _, err := launchArguments("test.mp4", opt); err == nil {
+			t.Errorf("%s: returned no error", name)
+		}
+	}
+}
+
+func TestWithFlag(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"no options", nil, nil},
+		{"flag", []Option{WithFlag("--no-osd")}, []string{"--no-osd"}},
+		{"flag with values", []Option{WithFlag("--win", "0,0,640,480")}, []string{"--win", "0,0,640,480"}},
+		{
+			"order",
+			[]Option{WithFlag("--no-osd"), WithFlag("-o", "hdmi"), WithFlag("--loop")},
+			[]string{"--no-osd", "-o", "hdmi", "--loop"},
+		},
+		{
+			"replaced",
+			[]Option{WithFlag("-o", "hdmi"), WithFlag("--no-osd"), WithFlag("-o", "local")},
+			[]string{"-o", "local", "--no-osd"},
+		},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"no dash": WithFlag("no-osd"),
+		"empty":   WithFlag(""),
+	})
+}
+
+func TestWithArgs(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"args", []Option{WithArgs("--no-osd", "--no-osd")}, []string{"--no-osd", "--no-osd"}},
+		{
+			"after flags",
+			[]Option{WithArgs("--extra"), WithFlag("--no-osd"), WithArgs("1")},
+			[]string{"--no-osd", "--extra", "1"},
+		},
+	})
+}
+
+func TestNewOptionError(t *testing.T) {
+	if _, err := NewWithOptions("test.mp4", WithExecutable(os.Args[0]), WithFlag("no-osd")); err == nil {
+		t.Error("returned no error for an invalid option")
+	}
+}
+
+func TestWithAudioOutput(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"hdmi", []Option{WithAudioOutput(AudioHDMI)}, []string{"-o", "hdmi"}},
+		{"local", []Option{WithAudioOutput(AudioLocal)}, []string{"-o", "local"}},
+		{"both", []Option{WithAudioOutput(AudioBoth)}, []string{"-o", "both"}},
+		{"alsa", []Option{WithAudioOutput(AudioALSA("hw:1,0"))}, []string{"-o", "alsa:hw:1,0"}},
+		{
+			"replaced",
+			[]Option{WithAudioOutput(AudioHDMI), WithAudioOutput(AudioLocal)},
+			[]string{"-o", "local"},
+		},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"empty":          WithAudioOutput(""),
+		"unknown":        WithAudioOutput("speaker"),
+		"alsa no device": WithAudioOutput(AudioALSA("")),
+		"case":           WithAudioOutput("HDMI"),
+	})
+}
+
+func TestWithWindow(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"window", []Option{WithWindow(0, 0, 640, 480)}, []string{"--win", "0,0,640,480"}},
+		{"offset", []Option{WithWindow(100, 50, 740, 530)}, []string{"--win", "100,50,740,530"}},
+		{
+			"replaced",
+			[]Option{WithWindow(0, 0, 640, 480), WithWindow(0, 0, 1920, 1080)},
+			[]string{"--win", "0,0,1920,1080"},
+		},
+	})
+
+	for name, opt := range map[string]Option{
+		"negative":  WithWindow(-1, 0, 640, 480),
+		"empty":     WithWindow(0, 0, 0, 480),
+		"inverted":  WithWindow(640, 480, 0, 0),
+		"no height": WithWindow(0, 480, 640, 480),
+	} {
+		if _, err := launchArguments("test.mp4", opt); !errors.Is(err, ErrInvalidRect) {
+			t.Errorf("%s: got error %v, want ErrInvalidRect", name, err)
+		}
+	}
+}
+
+func TestWithLoop(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"loop", []Option{WithLoop()}, []string{"--loop"}},
+		{"once", []Option{WithLoop(), WithLoop()}, []string{"--loop"}},
+	})
+
+	for _, url := range []string{"rtsp://camera/stream", "UDP://239.0.0.1:1234"} {
+		if _, err := launchArguments(url, WithLoop()); err == nil {
+			t.Errorf("looping %s returned no error", url)
+		}
+	}
+	if _, err := launchArguments("test.mp4", WithLoop(), WithLive()); err == nil {
+		t.Error("looping a live source returned no error")
+	}
+
+	fifo, remove := newTestFIFO(t)
+	defer remove()
+	if _, err := launchArguments(fifo, WithLoop()); err == nil {
+		t.Error("looping a named pipe returned no error")
+	}
+}
+
+func TestWithInitialVolume(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"full", []Option{WithInitialVolume(0)}, []string{"--vol", "0"}},
+		{"attenuated", []Option{WithInitialVolume(-2000)}, []string{"--vol", "-2000"}},
+		{"minimum", []Option{WithInitialVolume(-6000)}, []string{"--vol", "-6000"}},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"too loud":  WithInitialVolume(1),
+		"too quiet": WithInitialVolume(-6001),
+	})
+}
+
+func TestWithStartPosition(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"start", []Option{WithStartPosition(0)}, []string{"--pos", "00:00:00"}},
+		{"seconds", []Option{WithStartPosition(90 * time.Second)}, []string{"--pos", "00:01:30"}},
+		{
+			"over an hour",
+			[]Option{WithStartPosition(time.Hour + 2*time.Minute + 3*time.Second)},
+			[]string{"--pos", "01:02:03"},
+		},
+		{"over a day", []Option{WithStartPosition(25 * time.Hour)}, []string{"--pos", "25:00:00"}},
+		{"fraction", []Option{WithStartPosition(1500 * time.Millisecond)}, []string{"--pos", "1.500"}},
+		{
+			"fraction over an hour",
+			[]Option{WithStartPosition(time.Hour + 2*time.Minute + 3250*time.Millisecond)},
+			[]string{"--pos", "3723.250"},
+		},
+		{"truncated", []Option{WithStartPosition(2*time.Second + 999999*time.Microsecond)}, []string{"--pos", "2.999"}},
+		{"under a millisecond", []Option{WithStartPosition(time.Microsecond)}, []string{"--pos", "0.000"}},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"negative": WithStartPosition(-time.Second),
+	})
+}
+
+func TestWithAspectMode(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"letterbox", []Option{WithAspectMode(AspectLetterbox)}, []string{"--aspect-mode", "letterbox"}},
+		{"fill", []Option{WithAspectMode(AspectFill)}, []string{"--aspect-mode", "fill"}},
+		{"stretch", []Option{WithAspectMode(AspectStretch)}, []string{"--aspect-mode", "stretch"}},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"empty":   WithAspectMode(""),
+		"unknown": WithAspectMode("zoom"),
+	})
+}
+
+func TestWithOrientation(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"0", []Option{WithOrientation(0)}, []string{"--orientation", "0"}},
+		{"90", []Option{WithOrientation(90)}, []string{"--orientation", "90"}},
+		{"180", []Option{WithOrientation(180)}, []string{"--orientation", "180"}},
+		{"270", []Option{WithOrientation(270)}, []string{"--orientation", "270"}},
+		{"once", []Option{WithOrientation(90), WithOrientation(270)}, []string{"--orientation", "270"}},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"negative":  WithOrientation(-90),
+		"unaligned": WithOrientation(45),
+		"full turn": WithOrientation(360),
+	})
+}
+
+func TestWithLayer(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"layer", []Option{WithLayer(2)}, []string{"--layer", "2"}},
+		{"negative", []Option{WithLayer(-128)}, []string{"--layer", "-128"}},
+		{"replaced", []Option{WithLayer(1), WithLayer(3)}, []string{"--layer", "3"}},
+	})
+}
+
+func TestWithAlpha(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"transparent", []Option{WithAlpha(0)}, []string{"--alpha", "0"}},
+		{"translucent", []Option{WithAlpha(128)}, []string{"--alpha", "128"}},
+		{"opaque", []Option{WithAlpha(255)}, []string{"--alpha", "255"}},
+		{
+			"overlay",
+			[]Option{WithLayer(2), WithAlpha(200), WithWindow(0, 0, 640, 480)},
+			[]string{"--layer", "2", "--alpha", "200", "--win", "0,0,640,480"},
+		},
+	})
+}
+
+func TestWithDisplay(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"main lcd", []Option{WithDisplay(DisplayMainLCD)}, []string{"--display", "0"}},
+		{"hdmi0", []Option{WithDisplay(DisplayHDMI0)}, []string{"--display", "2"}},
+		{"hdmi1", []Option{WithDisplay(DisplayHDMI1)}, []string{"--display", "7"}},
+		{"replaced", []Option{WithDisplay(DisplayHDMI0), WithDisplay(DisplayHDMI1)}, []string{"--display", "7"}},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"negative": WithDisplay(-1),
+		"unknown":  WithDisplay(8),
+	})
+}
+
+func TestWithMode3D(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"side by side", []Option{WithMode3D(Mode3DSBS)}, []string{"--3d", "SBS"}},
+		{"top and bottom", []Option{WithMode3D(Mode3DTB)}, []string{"--3d", "TB"}},
+		{"frame packing", []Option{WithMode3D(Mode3DFP)}, []string{"--3d", "FP"}},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"empty":     WithMode3D(""),
+		"lowercase": WithMode3D("sbs"),
+	})
+}
+
+func TestWithPassthrough(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"passthrough", []Option{WithPassthrough()}, []string{"-p"}},
+		{"hw decode", []Option{WithHWAudioDecode()}, []string{"--hw"}},
+		{"both", []Option{WithPassthrough(), WithHWAudioDecode()}, []string{"-p", "--hw"}},
+		{"once", []Option{WithPassthrough(), WithPassthrough()}, []string{"-p"}},
+	})
+}
+
+func TestWithDeinterlace(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"auto", []Option{WithDeinterlace(DeinterlaceAuto)}, nil},
+		{"force", []Option{WithDeinterlace(DeinterlaceForce)}, []string{"--deinterlace"}},
+		{"native", []Option{WithDeinterlace(DeinterlaceNative)}, []string{"--nativedeinterlace"}},
+		{"off", []Option{WithDeinterlace(DeinterlaceOff)}, []string{"--nodeinterlace"}},
+		{
+			"replaced",
+			[]Option{WithDeinterlace(DeinterlaceForce), WithFlag("--no-osd"), WithDeinterlace(DeinterlaceOff)},
+			[]string{"--nodeinterlace", "--no-osd"},
+		},
+		{
+			"reset to auto",
+			[]Option{WithDeinterlace(DeinterlaceForce), WithDeinterlace(DeinterlaceAuto)},
+			nil,
+		},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"unknown": WithDeinterlace(DeinterlaceOff + 1),
+	})
+}
+
+func TestWithLive(t *testing.T) {
+	testURLArguments(t, "rtsp://camera/stream", []argumentTest{
+		{"live", []Option{WithLive()}, []string{"--live", "--threshold", "0.1"}},
+		{"threshold before", []Option{WithThreshold(2), WithLive()}, []string{"--threshold", "2", "--live"}},
+		{"threshold after", []Option{WithLive(), WithThreshold(0.5)}, []string{"--live", "--threshold", "0.5"}},
+	})
+}
+
+func TestWithAVDict(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"empty", []Option{WithAVDict(nil), WithAVDict(map[string]string{})}, nil},
+		{"rtsp over tcp", []Option{WithRTSPTransportTCP()}, []string{"--avdict", "rtsp_transport:tcp"}},
+		{
+			"sorted",
+			[]Option{WithAVDict(map[string]string{"timeout": "5000000", "buffer_size": "1024000", "rtsp_flags": "prefer_tcp"})},
+			[]string{"--avdict", "buffer_size:1024000,rtsp_flags:prefer_tcp,timeout:5000000"},
+		},
+		{
+			"merged",
+			[]Option{WithAVDict(map[string]string{"timeout": "1"}), WithRTSPTransportTCP(), WithAVDict(map[string]string{"timeout": "2"})},
+			[]string{"--avdict", "rtsp_transport:tcp,timeout:2"},
+		},
+		{
+			"escaped",
+			[]Option{WithAVDict(map[string]string{"headers": `a:b,c\d`, "k:1": ""})},
+			[]string{"--avdict", `headers:a\:b\,c\\d,k\:1:`},
+		},
+		{
+			"after other flags",
+			[]Option{WithRTSPTransportTCP(), WithFlag("--live")},
+			[]string{"--live", "--avdict", "rtsp_transport:tcp"},
+		},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"empty key": WithAVDict(map[string]string{"": "tcp"}),
+	})
+}
+
+func TestWithUserAgentCookie(t *testing.T) {
+	userAgent := `Mozilla/5.0 (X11; Linux armv7l) "quoted" $HOME`
+	cookie := "session=abc; token=d\\e'f"
+	testArguments(t, []argumentTest{
+		{"user agent", []Option{WithUserAgent(userAgent)}, []string{"--user-agent", userAgent}},
+		{"cookie", []Option{WithCookie(cookie)}, []string{"--cookie", cookie}},
+		{
+			"both",
+			[]Option{WithCookie("a=1"), WithUserAgent("agent"), WithCookie("a=2")},
+			[]string{"--cookie", "a=2", "--user-agent", "agent"},
+		},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"empty user agent": WithUserAgent(""),
+		"empty cookie":     WithCookie(""),
+	})
+}
+
+func TestWithBufferTuning(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"threshold", []Option{WithThreshold(0.25)}, []string{"--threshold", "0.25"}},
+		{"video queue", []Option{WithVideoQueue(10)}, []string{"--video_queue", "10"}},
+		{"audio queue", []Option{WithAudioQueue(1.5)}, []string{"--audio_queue", "1.5"}},
+		{"video fifo", []Option{WithVideoFifo(3)}, []string{"--video_fifo", "3"}},
+		{"audio fifo", []Option{WithAudioFifo(0.5)}, []string{"--audio_fifo", "0.5"}},
+		{
+			"network tuning",
+			[]Option{WithNetworkTuning()},
+			[]string{"--threshold", "5", "--video_queue", "4", "--audio_queue", "4", "--video_fifo", "2"},
+		},
+		{
+			"network tuning overridden",
+			[]Option{WithNetworkTuning(), WithVideoQueue(8)},
+			[]string{"--threshold", "5", "--video_queue", "8", "--audio_queue", "4", "--video_fifo", "2"},
+		},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"zero threshold":       WithThreshold(0),
+		"negative video queue": WithVideoQueue(-1),
+		"nan audio queue":      WithAudioQueue(math.NaN()),
+		"zero video fifo":      WithVideoFifo(0),
+		"negative audio fifo":  WithAudioFifo(-0.5),
+	})
+}
+
+func TestWithSubtitles(t *testing.T) {
+	f, err := ioutil.TempFile("", "omxplayer-test-*.srt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+
+	testArguments(t, []argumentTest{
+		{"file", []Option{WithSubtitles(f.Name())}, []string{"--subtitles", f.Name()}},
+		{"font size", []Option{WithSubtitleFontSize(55)}, []string{"--font-size", "55"}},
+		{"align left", []Option{WithSubtitleAlign(SubtitleAlignLeft)}, []string{"--align", "left"}},
+		{"align center", []Option{WithSubtitleAlign(SubtitleAlignCenter)}, []string{"--align", "center"}},
+		{"lines", []Option{WithSubtitleLines(2)}, []string{"--lines", "2"}},
+		{"no ghost box", []Option{WithNoGhostBox()}, []string{"--no-ghost-box"}},
+		{
+			"styled",
+			[]Option{WithSubtitles(f.Name()), WithSubtitleFontSize(40), WithSubtitleLines(3), WithNoGhostBox()},
+			[]string{"--subtitles", f.Name(), "--font-size", "40", "--lines", "3", "--no-ghost-box"},
+		},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"zero font size": WithSubtitleFontSize(0),
+		"right align":    WithSubtitleAlign("right"),
+		"negative lines": WithSubtitleLines(-1),
+	})
+
+	missing := WithSubtitles(f.Name() + ".missing")
+	if _, err = launchArguments("test.mp4", missing); !errors.Is(err, ErrSubtitlesNotFound) {
+		t.Errorf("got error %v for a missing file, want ErrSubtitlesNotFound", err)
+	}
+}
+
+func TestWithNoOSDNoKeys(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"no osd", []Option{WithNoOSD()}, []string{"--no-osd"}},
+		{"no keys", []Option{WithNoKeys()}, []string{"--no-keys"}},
+		{"both", []Option{WithNoKeys(), WithNoOSD(), WithNoKeys()}, []string{"--no-keys", "--no-osd"}},
+	})
+}
+
+func TestWithBlankBackground(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"blank", []Option{WithBlankBackground()}, []string{"-b"}},
+		{"black", []Option{WithBackgroundColor(0xFF000000)}, []string{"--blank=0xFF000000"}},
+		{"padded", []Option{WithBackgroundColor(0x00ABCDEF)}, []string{"--blank=0x00ABCDEF"}},
+		{"zero", []Option{WithBackgroundColor(0)}, []string{"--blank=0x00000000"}},
+		{
+			"colour replaces blank",
+			[]Option{WithBlankBackground(), WithNoOSD(), WithBackgroundColor(0xFF00FF00)},
+			[]string{"--blank=0xFF00FF00", "--no-osd"},
+		},
+		{"blank replaces colour", []Option{WithBackgroundColor(0xFF00FF00), WithBlankBackground()}, []string{"-b"}},
+	})
+}
+
+func TestWithAmplification(t *testing.T) {
+	testArguments(t, []argumentTest{
+		{"boost", []Option{WithAmplification(600)}, []string{"--amp", "600"}},
+		{"cut", []Option{WithAmplification(-6000)}, []string{"--amp", "-6000"}},
+		{
+			"with initial volume",
+			[]Option{WithInitialVolume(-600), WithAmplification(600)},
+			[]string{"--vol", "-600", "--amp", "600"},
+		},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"too loud":  WithAmplification(6001),
+		"too quiet": WithAmplification(-6001),
+	})
+}
+
+func TestWithExecutable(t *testing.T) {
+	for _, path := range []string{"/nonexistent/omxplayer", "omxplayer-test-missing"} {
+		if _, err := launchArguments("test.mp4", WithExecutable(path)); !errors.Is(err, ErrExecutableNotFound) {
+			t.Errorf("%s: got error %v, want ErrExecutableNotFound", path, err)
+		}
+	}
+	testInvalidOptions(t, map[string]Option{
+		"empty executable": WithExecutable(""),
+		"env without =":    WithEnv([]string{"LD_LIBRARY_PATH"}),
+	})
+
+	config := &Config{}
+	for _, opt := range []Option{WithEnv([]string{"A=1"}), WithEnv([]string{"B=", "C=x=y"})} {
+		if err := opt(config); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"A=1", "B=", "C=x=y"}; !reflect.DeepEqual(config.env, want) {
+		t.Errorf("got environment %q, want %q", config.env, want)
+	}
+}
+
+func TestWithDBusName(t *testing.T) {
+	name := "org.mpris.MediaPlayer2.omxplayer.left"
+	testArguments(t, []argumentTest{
+		{"name", []Option{WithDBusName(name)}, []string{"--dbus_name", name}},
+	})
+	testInvalidOptions(t, map[string]Option{
+		"empty": WithDBusName(""),
+	})
+
+	config := &Config{}
+	if got := config.busName(); got != ifaceOmx {
+		t.Errorf("got default bus name %q, want %q", got, ifaceOmx)
+	}
+	if err := WithDBusName(name)(config); err != nil {
+		t.Fatal(err)
+	}
+	if got := config.busName(); got != name {
+		t.Errorf("got bus name %q, want %q", got, name)
+	}
+}
@@ -0,0 +1,139 @@
+package omxplayer
+
+import (
+	"io"
+	"sync"
+)
+
+const (
+	// lastOutputSize is the number of bytes of the omxplayer process's standard
+	// error that are kept for LastOutput.
+	lastOutputSize = 8 << 10
+
+	// outputQueueSize is the number of writes of the omxplayer process's output
+	// that are queued for a writer before further output is dropped.
+	outputQueueSize = 64
+)
+
+// LastOutput returns the last few kilobytes written by the omxplayer process to
+// its standard error, which usually explains why playback failed. The output
+// is kept whether or not a writer was set with WithStderr.
+func (p *Player) LastOutput() string {
+	return p.output.last.String()
+}
+
+// processOutput collects the output of an omxplayer process.
+type processOutput struct {
+	stdout *asyncWriter
+	stderr *asyncWriter
+	last   *ringBuffer
+}
+
+// newProcessOutput returns the processOutput for a process launched with the
+// specified configuration.
+func newProcessOutput(config *Config) *processOutput {
+	o := &processOutput{last: newRingBuffer(lastOutputSize)}
+	if config.stdout != nil {
+		o.stdout = newAsyncWriter(config.stdout)
+	}
+	if config.stderr != nil {
+		o.stderr = newAsyncWriter(config.stderr)
+	}
+	return o
+}
+
+// stdoutWriter returns the writer the process's standard output is written to,
+// or nil if it is discarded.
+func (o *processOutput) stdoutWriter() io.Writer {
+	if o.stdout == nil {
+		return nil
+	}
+	return o.stdout
+}
+
+// stderrWriter returns the writer the process's standard error is written to.
+func (o *processOutput) stderrWriter() io.Writer {
+	if o.stderr == nil {
+		return o.last
+	}
+	return io.MultiWriter(o.last, o.stderr)
+}
+
+// close stops forwarding output to the writers once the process has exited.
+func (o *processOutput) close() {
+	if o.stdout != nil {
+		o.stdout.close()
+	}
+	if o.stderr != nil {
+		o.stderr.close()
+	}
+}
+
+// asyncWriter forwards writes to another writer from a separate goroutine, so
+// that a slow or stalled writer never blocks the omxplayer process or its
+// shutdown. Output that arrives while the queue is full is dropped.
+type asyncWriter struct {
+	w     io.Writer
+	queue chan []byte
+}
+
+// newAsyncWriter returns an asyncWriter that forwards writes to w.
+func newAsyncWriter(w io.Writer) *asyncWriter {
+	a := &asyncWriter{w: w, queue: make(chan []byte, outputQueueSize)}
+	go a.forward()
+	return a
+}
+
+// Write queues a copy of b to be written and never fails.
+func (a *asyncWriter) Write(b []byte) (int, error) {
+	select {
+	case a.queue <- append([]byte(nil), b...):
+	default:
+		debugf("omxplayer: dropped %d bytes of process output", len(b))
+	}
+	return len(b), nil
+}
+
+// forward writes the queued output until the writer is closed.
+func (a *asyncWriter) forward() {
+	for b := range a.queue {
+		a.w.Write(b)
+	}
+}
+
+// close stops the writer once the queued output has been written. It must not
+// be called while Write may still be called.
+func (a *asyncWriter) close() {
+	close(a.queue)
+}
+
+// ringBuffer keeps the last size bytes written to it.
+type ringBuffer struct {
+	mu   sync.Mutex
+	buf  []byte
+	size int
+}
+
+// newRingBuffer returns a ringBuffer that keeps the last size bytes.
+func newRingBuffer(size int) *ringBuffer {
+	return &ringBuffer{size: size}
+}
+
+// Write appends b to the buffer, discarding the oldest bytes beyond its size.
+func (r *ringBuffer) Write(b []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.buf = append(r.buf, b...)
+	if len(r.buf) > r.size {
+		r.buf = append(r.buf[:0], r.buf[len(r.buf)-r.size:]...)
+	}
+	return len(b), nil
+}
+
+// String returns the bytes kept in the buffer.
+func (r *ringBuffer) String() string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return string(r.buf)
+}
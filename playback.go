@@ -0,0 +1,154 @@
+package omxplayer
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	statusPlaying = "Playing"
+	statusPaused  = "Paused"
+	statusStopped = "Stopped"
+
+	// stepInterval is the pause between the frames stepped by StepN, which
+	// gives omxplayer time to display each frame.
+	stepInterval = 50 * time.Millisecond
+)
+
+// Resume makes sure the video is playing. Unlike PlayPause, it first checks the
+// playback status and only toggles playback if the video is not playing yet, so
+// calling it repeatedly has no further effect.
+func (p *Player) Resume() error {
+	return p.setPlaying(true)
+}
+
+// PauseOnly makes sure the video is paused. Unlike PlayPause, it first checks the
+// playback status and only toggles playback if the video is playing, so calling
+// it repeatedly has no further effect.
+func (p *Player) PauseOnly() error {
+	return p.setPlaying(false)
+}
+
+// setPlaying toggles playback if the video is not already in the desired state.
+func (p *Player) setPlaying(playing bool) error {
+	status, err := p.PlaybackStatus()
+	if err != nil {
+		return err
+	}
+	if (status == statusPlaying) == playing {
+		return nil
+	}
+	return p.PlayPause()
+}
+
+// Loop restarts the video from the beginning whenever it is about to end, as an
+// alternative to WithLoop for sources omxplayer does not loop well. omxplayer
+// exits when it reaches the end of the video, so Loop polls the position at the
+// specified interval and seeks back to the start as soon as less than interval
+// of playback remains; shorter intervals cut off less of the end of the video.
+// Loop blocks until ctx is cancelled, in which case ctx.Err() is returned, or
+// until the omxplayer process exits, in which case nil is returned.
+func (p *Player) Loop(ctx context.Context, interval time.Duration) error {
+	if interval <= 0 {
+		return fmt.Errorf("omxplayer: invalid loop interval: %s", interval)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-p.exited:
+			return nil
+		case <-ticker.C:
+		}
+
+		if err := p.restartIfEnding(interval); err != nil {
+			p.debugf("omxplayer: failed to loop video: %v", err)
+		}
+	}
+}
+
+// restartIfEnding seeks to the start of the video if it is playing and less
+// than remaining of it is left.
+func (p *Player) restartIfEnding(remaining time.Duration) error {
+	position, err := p.PositionDuration()
+	if err != nil {
+		return err
+	}
+	duration, err := p.DurationTime()
+	if err != nil {
+		return err
+	}
+	if duration <= 0 || duration-position > remaining {
+		return nil
+	}
+
+	status, err := p.PlaybackStatus()
+	if err != nil || status != statusPlaying {
+		return err
+	}
+	_, err = p.SetPosition(pathMpris, 0)
+	return err
+}
+
+// Step pauses the video if it is playing and then advances it by a single
+// frame, like the . key does. ErrStopped is returned if the player has stopped.
+func (p *Player) Step() error {
+	select {
+	case <-p.exited:
+		return ErrStopped
+	default:
+	}
+
+	status, err := p.PlaybackStatus()
+	if err != nil {
+		return err
+	}
+	switch status {
+	case statusStopped:
+		return ErrStopped
+	case statusPlaying:
+		if err = p.Pause(); err != nil {
+			return err
+		}
+	}
+	return p.Action(ActionStep)
+}
+
+// StepN advances the video by n frames like Step does, waiting briefly between
+// frames. An error is returned if n is not positive.
+func (p *Player) StepN(n int) error {
+	if n <= 0 {
+		return fmt.Errorf("omxplayer: invalid number of frames to step: %d", n)
+	}
+
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			time.Sleep(stepInterval)
+		}
+		if err := p.Step(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restart seeks back to the start of the video and, if it is paused, resumes
+// playback, then returns the position reported by the player. If the source
+// cannot be seeked in, the error from SetPosition is returned. Restart does not
+// fall back to stopping and replaying the video, since Stop terminates the
+// omxplayer process; launch a new Player instead.
+func (p *Player) Restart() (time.Duration, error) {
+	position, err := p.SetPosition(pathMpris, 0)
+	if err != nil {
+		return 0, err
+	}
+	if err = p.Resume(); err != nil {
+		return 0, err
+	}
+	return fromMicroseconds(position), nil
+}
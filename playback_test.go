@@ -0,0 +1,142 @@
+package omxplayer
+
+import (
+	"testing"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// handlePlayback makes the fakeCaller keep a playback status, starting at the
+// specified one, that PlayPause and Pause toggle like omxplayer does.
+func (f *fakeCaller) handlePlayback(status string) {
+	f.handle(propPlaybackStatus, func([]interface{}) *dbus.Call {
+		return &dbus.Call{Body: []interface{}{status}}
+	})
+	toggle := func([]interface{}) *dbus.Call {
+		if status == statusPlaying {
+			status = statusPaused
+		} else {
+			status = statusPlaying
+		}
+		return &dbus.Call{}
+	}
+	f.handle(cmdPlayPause, toggle)
+	f.handle(cmdPause, toggle)
+}
+
+func TestResumePauseOnly(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    string
+		fn        func(*Player) error
+		playPause int
+	}{
+		{"resume paused", statusPaused, (*Player).Resume, 1},
+		{"resume playing", statusPlaying, (*Player).Resume, 0},
+		{"pause playing", statusPlaying, (*Player).PauseOnly, 1},
+		{"pause paused", statusPaused, (*Player).PauseOnly, 0},
+	}
+	for _, tt := range tests {
+		bus := newFakeCaller()
+		bus.handlePlayback(tt.status)
+		p := newTestPlayer(t, bus)
+
+		for i := 0; i < 3; i++ {
+			if err := tt.fn(p); err != nil {
+				t.Fatalf("%s: returned error: %v", tt.name, err)
+			}
+		}
+		if n := len(bus.callsTo(cmdPlayPause)); n != tt.playPause {
+			t.Errorf("%s: got %d PlayPause calls, want %d", tt.name, n, tt.playPause)
+		}
+	}
+}
+
+func TestResumeStatusFailure(t *testing.T) {
+	bus := newFakeCaller()
+	bus.fail(propPlaybackStatus, dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"})
+	bus.respond(cmdPlayPause)
+	p := newTestPlayer(t, bus)
+
+	if err := p.Resume(); err == nil {
+		t.Error("returned no error")
+	}
+	if n := len(bus.callsTo(cmdPlayPause)); n != 0 {
+		t.Errorf("got %d PlayPause calls without a playback status, want 0", n)
+	}
+}
+
+func TestStep(t *testing.T) {
+	tests := []struct {
+		status string
+		pauses int
+	}{
+		{statusPlaying, 1},
+		{statusPaused, 0},
+	}
+	for _, tt := range tests {
+		bus := newFakeCaller()
+		bus.respond(propPlaybackStatus, tt.status)
+		bus.respond(cmdPause)
+		bus.respond(cmdAction)
+		p := newTestPlayer(t, bus)
+
+		if err := p.Step(); err != nil {
+			t.Errorf("%s: returned error: %v", tt.status, err)
+			continue
+		}
+		if n := len(bus.callsTo(cmdPause)); n != tt.pauses {
+			t.Errorf("%s: got %d Pause calls, want %d", tt.status, n, tt.pauses)
+		}
+		if actions := bus.actions(); !equalActions(actions, []int32{ActionStep}) {
+			t.Errorf("%s: sent actions %v, want [%d]", tt.status, actions, ActionStep)
+		}
+	}
+}
+
+func TestStepStopped(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(propPlaybackStatus, statusStopped)
+	bus.respond(cmdAction)
+	p := newTestPlayer(t, bus)
+
+	if err := p.Step(); err != ErrStopped {
+		t.Errorf("got error %v for a stopped player, want ErrStopped", err)
+	}
+
+	bus.respond(propPlaybackStatus, statusPaused)
+	close(p.exited)
+	if err := p.Step(); err != ErrStopped {
+		t.Errorf("got error %v after the process exited, want ErrStopped", err)
+	}
+	if actions := bus.actions(); len(actions) != 0 {
+		t.Errorf("sent actions %v, want none", actions)
+	}
+}
+
+func TestStepN(t *testing.T) {
+	bus := newFakeCaller()
+	bus.handlePlayback(statusPlaying)
+	bus.respond(cmdAction)
+	p := newTestPlayer(t, bus)
+
+	if err := p.StepN(3); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(bus.callsTo(cmdPause)); n != 1 {
+		t.Errorf("got %d Pause calls, want 1", n)
+	}
+	want := []int32{ActionStep, ActionStep, ActionStep}
+	if actions := bus.actions(); !equalActions(actions, want) {
+		t.Errorf("sent actions %v, want %v", actions, want)
+	}
+
+	for _, n := range []int{0, -1} {
+		if err := p.StepN(n); err == nil {
+			t.Errorf("StepN(%d) returned no error", n)
+		}
+	}
+	if actions := bus.actions(); len(actions) != len(want) {
+		t.Errorf("sent %d actions after invalid calls, want %d", len(actions), len(want))
+	}
+}
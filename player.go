@@ -1,13 +1,17 @@
 package omxplayer
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sync"
 	"syscall"
 	"time"
 
 	dbus "github.com/godbus/dbus/v5"
-	log "github.com/sirupsen/logrus"
 )
 
 const (
@@ -60,19 +64,162 @@ const (
 	cmdShowSubtitles        = ifaceOmxPlayer + ".ShowSubtitles"
 	cmdHideSubtitles        = ifaceOmxPlayer + ".HideSubtitles"
 	cmdAction               = ifaceOmxPlayer + ".Action"
+	cmdSetVideoPos          = ifaceOmxPlayer + ".SetVideoPos"
+	cmdSetAlpha             = ifaceOmxPlayer + ".SetAlpha"
+	cmdSetAspectMode        = ifaceOmxPlayer + ".SetAspectMode"
+	cmdGetSource            = ifaceOmxPlayer + ".GetSource"
+	cmdSetVideoCropPos      = ifaceOmxPlayer + ".SetVideoCropPos"
+	cmdSetLayer             = ifaceOmxPlayer + ".SetLayer"
+	cmdOpenURI              = ifaceOmxPlayer + ".OpenUri"
 )
 
-// The Player struct provides access to all of omxplayer's D-Bus methods.
+// The Player struct provides access to all of omxplayer's D-Bus methods. Its
+// methods are safe for concurrent use by multiple goroutines.
 type Player struct {
-	command    *exec.Cmd
-	connection *dbus.Conn
-	bus        *dbus.Object
-	ready      bool
+	command *exec.Cmd
+	args    []string
+	config  *Config
+	output  *processOutput
+	exited  chan struct{}
+	exitErr error
+	stream  *streamCopy
+
+	// volumeMu serializes the read-modify-write volume changes.
+	volumeMu sync.Mutex
+
+	// mu guards the D-Bus connection and the state of the player that is
+	// tracked locally because omxplayer does not report it.
+	mu               sync.RWMutex
+	connection       *dbus.Conn
+	bus              caller
+	url              string
+	ready            bool
+	muted            bool
+	mutedVolume      float64
+	volumeChanged    bool
+	videoPos         *Rect
+	aspectMode       AspectMode
+	alpha            *uint8
+	layer            *int
+	subtitleDelay    time.Duration
+	speedIndex       int
+	scanIndex        int
+	chapter          int
+	subtitlesVisible *bool
+	cancelFade       context.CancelFunc
+	fades            int
+}
+
+// Args returns the complete list of arguments the omxplayer process was
+// launched with, which can be useful for debugging.
+func (p *Player) Args() []string {
+	return append([]string(nil), p.args...)
+}
+
+// AudioOutput returns the audio output that was requested when the player was
+// launched, or an empty AudioOutput if omxplayer's default was used.
+func (p *Player) AudioOutput() AudioOutput {
+	return p.config.audioOutput
+}
+
+// Window returns the rectangle the video was requested to be played in when the
+// player was launched. If no window was requested, ok is false.
+func (p *Player) Window() (window Rect, ok bool) {
+	if p.config.window == nil {
+		return Rect{}, false
+	}
+	return *p.config.window, true
+}
+
+// AspectMode returns the aspect mode last applied with SetAspectMode, or else
+// the one that was requested when the player was launched. An empty AspectMode
+// is returned if omxplayer's default is in use.
+func (p *Player) AspectMode() AspectMode {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.aspectMode != "" {
+		return p.aspectMode
+	}
+	return p.config.aspectMode
+}
+
+// Orientation returns the rotation, in degrees, that was requested when the
+// player was launched.
+func (p *Player) Orientation() int {
+	return p.config.orientation
+}
+
+// Layer returns the dispmanx layer the video is displayed on, as last applied
+// with SetLayer or else as requested when the player was launched.
+func (p *Player) Layer() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.layer != nil {
+		return *p.layer
+	}
+	return p.config.layer
+}
+
+// Alpha returns the opacity of the video last applied with SetAlpha, or else the
+// one that was requested when the player was launched. If neither was set, the
+// video is fully opaque and 255 is returned.
+func (p *Player) Alpha() uint8 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	switch {
+	case p.alpha != nil:
+		return *p.alpha
+	case p.config.alpha != nil:
+		return *p.config.alpha
+	}
+	return 255
+}
+
+// Display returns the dispmanx ID of the display that was requested when the
+// player was launched.
+func (p *Player) Display() int {
+	return p.config.display
+}
+
+// Passthrough returns true if the player was launched with the WithPassthrough
+// option.
+func (p *Player) Passthrough() bool {
+	return p.config.passthrough
+}
+
+// HWAudioDecode returns true if the player was launched with the
+// WithHWAudioDecode option.
+func (p *Player) HWAudioDecode() bool {
+	return p.config.hwAudioDecode
+}
+
+// Executable returns the path of the omxplayer executable the player was
+// launched with.
+func (p *Player) Executable() string {
+	return p.config.executable
+}
+
+// IsLooping returns true if the player was launched with the WithLoop option.
+func (p *Player) IsLooping() bool {
+	return p.config.loop
+}
+
+// IsLiveSource returns true if the player was launched with the WithLive
+// option, in which case seeking is not meaningful.
+func (p *Player) IsLiveSource() bool {
+	return p.config.live
 }
 
 // IsRunning checks to see if the OMXPlayer process is running. If it is, the
 // function returns true, otherwise it returns false.
 func (p *Player) IsRunning() bool {
+	if p.command == nil || p.command.Process == nil {
+		return false
+	}
+
 	pid := p.command.Process.Pid
 	process, err := os.FindProcess(pid)
 	if err != nil {
@@ -80,7 +227,7 @@ func (p *Player) IsRunning() bool {
 	}
 
 	err = process.Signal(syscall.Signal(0))
-	return err != nil
+	return err == nil
 }
 
 // Wait blocks till the running omxplayer instance ends and sends a signal through
@@ -88,172 +235,318 @@ func (p *Player) IsRunning() bool {
 // mostly so we can tell when the video it was playing ended without having to
 // Poll IsRunning().
 func (p *Player) Wait(status chan error) {
-	status <- p.command.Wait()
+	<-p.exited
+	status <- p.exitErr
+}
+
+// WaitForExit blocks until the omxplayer process exits, for example because the
+// video it was playing ended. It returns nil if the process exited normally, the
+// exit error if it failed, or ctx.Err() if ctx is cancelled first. It is safe to
+// call from several goroutines at once; all of them receive the same result.
+func (p *Player) WaitForExit(ctx context.Context) error {
+	select {
+	case <-p.exited:
+		return p.exitErr
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// reap waits for the omxplayer process to exit and records its exit status. It
+// is started once for every launched process so that the process is always
+// reaped, no matter how many callers are waiting on it.
+func (p *Player) reap() {
+	p.exitErr = p.command.Wait()
+	p.output.close()
+	p.mu.Lock()
 	p.ready = false
+	p.mu.Unlock()
+	p.removePIDFile()
+	close(p.exited)
+}
+
+// Kill terminates the omxplayer process immediately with SIGKILL. If the player
+// was launched with WithProcessGroup, every process in its process group is
+// killed.
+func (p *Player) Kill() error {
+	return p.signal(syscall.SIGKILL)
+}
+
+// closeTimeout is how long Close waits for omxplayer to exit after quitting it
+// before killing it. It is a variable so that tests can shorten it.
+var closeTimeout = 5 * time.Second
+
+// Close quits omxplayer if it is still running, waits for the process to exit
+// and releases the resources held by the Player. If omxplayer cannot be quit, or
+// has not exited five seconds after quitting, it is killed. For players created
+// with NewFromReader, Close also stops copying the stream and closes and removes
+// the FIFO it was copied into. The reader itself is not closed; if it is blocked
+// in Read, Close waits at most a second for it to return. For players returned
+// by Connect, Close only closes the D-Bus connection.
+func (p *Player) Close() error {
+	if p.command == nil {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		return p.connection.Close()
+	}
+
+	select {
+	case <-p.exited:
+	default:
+		if err := p.Quit(); err != nil || !p.exitedWithin(closeTimeout) {
+			p.debugf("omxplayer: killing omxplayer after it did not quit: error=%v", err)
+			p.Kill()
+			<-p.exited
+		}
+	}
+
+	if p.stream != nil {
+		p.stream.close()
+		removeDir(filepath.Dir(p.stream.path))
+	}
+	p.removePIDFile()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.connection == nil {
+		return nil
+	}
+	return p.connection.Close()
 }
 
 // IsReady checks to see if the Player instance is ready to accept D-Bus
 // commands. If the player is ready and can accept commands, the function
-// returns true, otherwise it returns false.
+// returns true, otherwise it returns false. The player is only considered ready
+// once CanQuit succeeds; that result is cached until the process exits.
 func (p *Player) IsReady() bool {
-	if p.ready {
+	p.mu.RLock()
+	ready := p.ready
+	p.mu.RUnlock()
+	if ready {
 		return true
 	}
 
-	result, err := p.CanQuit()
-	if err == nil && result {
-		p.ready = true
+	call := p.object().Call(propCanQuit, 0)
+	if call.Err == nil && len(call.Body) > 0 {
+		ready, _ = call.Body[0].(bool)
 	}
-	return p.ready
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.ready = ready
+	return ready
 }
 
 // WaitForReady waits until the Player instance is ready to accept D-Bus
 // commands and then returns.
 func (p *Player) WaitForReady() {
+	p.WaitForReadyContext(context.Background())
+}
+
+// WaitForReadyContext waits until the Player instance is ready to accept D-Bus
+// commands and then returns nil. If ctx is cancelled or its deadline expires
+// first, ctx.Err() is returned instead.
+func (p *Player) WaitForReadyContext(ctx context.Context) error {
 	for !p.IsReady() {
-		time.Sleep(50 * time.Millisecond)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(50 * time.Millisecond):
+		}
 	}
+	return nil
 }
 
 // Quit stops the currently playing video and terminates the omxplayer process.
 // See https://github.com/popcornmix/omxplayer#quit for more details.
 func (p *Player) Quit() error {
-	return dbusCall(p.bus, cmdQuit)
+	if err := p.dbusCall(cmdQuit); err != nil {
+		return err
+	}
+	p.removePIDFile()
+	return nil
+}
+
+// QuitWithTimeout quits omxplayer like Quit, but waits at most d for the process
+// to exit. If it is still running after that, it is sent SIGTERM and, if it
+// still has not exited after another d, SIGKILL. When either signal had to be
+// sent, the returned error wraps ErrQuitTimeout and describes which signal
+// finally stopped the process.
+func (p *Player) QuitWithTimeout(d time.Duration) error {
+	quitErr := p.Quit()
+	if p.exitedWithin(d) {
+		return nil
+	}
+
+	reason := "did not exit"
+	if quitErr != nil {
+		reason = "quit failed: " + quitErr.Error()
+	}
+
+	if p.signal(syscall.SIGTERM) == nil && p.exitedWithin(d) {
+		return fmt.Errorf("%w (%s), terminated with SIGTERM", ErrQuitTimeout, reason)
+	}
+
+	if err := p.Kill(); err != nil {
+		return err
+	}
+	<-p.exited
+	return fmt.Errorf("%w (%s), killed with SIGKILL", ErrQuitTimeout, reason)
+}
+
+// exitedWithin waits at most d for the omxplayer process to exit and returns
+// true if it did.
+func (p *Player) exitedWithin(d time.Duration) bool {
+	select {
+	case <-p.exited:
+		return true
+	case <-time.After(d):
+		return false
+	}
 }
 
 // CanQuit returns true if the player can quit, false otherwise. See
 // https://github.com/popcornmix/omxplayer#canquit for more details.
 func (p *Player) CanQuit() (bool, error) {
-	return dbusGetBool(p.bus, propCanQuit)
+	return p.dbusGetBool(propCanQuit)
 }
 
 // Fullscreen returns true if the player is fullscreen, false otherwise. See
 // https://github.com/popcornmix/omxplayer#fullscreen for more details.
 func (p *Player) Fullscreen() (bool, error) {
-	return dbusGetBool(p.bus, propFullscreen)
+	return p.dbusGetBool(propFullscreen)
 }
 
 // CanSetFullscreen returns true if the player can be set to fullscreen, false
 // otherwise. See https://github.com/popcornmix/omxplayer#cansetfullscreen for
 // more details.
 func (p *Player) CanSetFullscreen() (bool, error) {
-	return dbusGetBool(p.bus, propCanSetFullscreen)
+	return p.dbusGetBool(propCanSetFullscreen)
 }
 
 // CanRaise returns true if the player can be brought to the front, false
 // otherwise. See https://github.com/popcornmix/omxplayer#canraise for more
 // details.
 func (p *Player) CanRaise() (bool, error) {
-	return dbusGetBool(p.bus, propCanRaise)
+	return p.dbusGetBool(propCanRaise)
 }
 
 // HasTrackList returns true if the player has a track list, false otherwise.
 // See https://github.com/popcornmix/omxplayer#hastracklist for more details.
 func (p *Player) HasTrackList() (bool, error) {
-	return dbusGetBool(p.bus, propHasTrackList)
+	return p.dbusGetBool(propHasTrackList)
 }
 
 // Identity returns the name of the player instance. See
 // https://github.com/popcornmix/omxplayer#identity for more details.
 func (p *Player) Identity() (string, error) {
-	return dbusGetString(p.bus, propIdentity)
+	return p.dbusGetString(propIdentity)
 }
 
 // SupportedURISchemes returns a list of playable URI formats. See
 // https://github.com/popcornmix/omxplayer#supportedurischemes for more details.
 func (p *Player) SupportedURISchemes() ([]string, error) {
-	return dbusGetStringArray(p.bus, propSupportedURISchemes)
+	return p.dbusGetStringArray(propSupportedURISchemes)
 }
 
 // SupportedMimeTypes returns a list of supported MIME types. See
 // https://github.com/popcornmix/omxplayer#supportedmimetypes for more details.
 func (p *Player) SupportedMimeTypes() ([]string, error) {
-	return dbusGetStringArray(p.bus, propSupportedMimeTypes)
+	return p.dbusGetStringArray(propSupportedMimeTypes)
 }
 
 // CanGoNext returns true if the player can skip to the next track, false
 // otherwise. See https://github.com/popcornmix/omxplayer#cangonext for more
 // details.
 func (p *Player) CanGoNext() (bool, error) {
-	return dbusGetBool(p.bus, propCanGoNext)
+	return p.dbusGetBool(propCanGoNext)
 }
 
 // CanGoPrevious returns true if the player can skip to previous track, false
 // otherwise. See https://github.com/popcornmix/omxplayer#cangoprevious for more
 // details.
 func (p *Player) CanGoPrevious() (bool, error) {
-	return dbusGetBool(p.bus, propCanGoPrevious)
+	return p.dbusGetBool(propCanGoPrevious)
 }
 
 // CanSeek returns true if the player can seek, false otherwise. See
 // https://github.com/popcornmix/omxplayer#canseek for more details.
 func (p *Player) CanSeek() (bool, error) {
-	return dbusGetBool(p.bus, cmdSeek)
+	return p.dbusGetBool(propCanSeek)
 }
 
 // CanControl returns true if the player can be controlled, false otherwise. See
 // https://github.com/popcornmix/omxplayer#cancontrol for more details.
 func (p *Player) CanControl() (bool, error) {
-	return dbusGetBool(p.bus, propCanControl)
+	return p.dbusGetBool(propCanControl)
 }
 
 // CanPlay returns true if the player can play, false otherwise. See
 // https://github.com/popcornmix/omxplayer#canplay for more details.
 func (p *Player) CanPlay() (bool, error) {
-	return dbusGetBool(p.bus, propCanPlay)
+	return p.dbusGetBool(propCanPlay)
 }
 
 // CanPause returns true if the player can pause, false otherwise. See
 // https://github.com/popcornmix/omxplayer#canpause for more details.
 func (p *Player) CanPause() (bool, error) {
-	return dbusGetBool(p.bus, propCanPause)
+	return p.dbusGetBool(propCanPause)
 }
 
 // Next tells the player to skip to the next chapter. See
 // https://github.com/popcornmix/omxplayer#next for more details.
 func (p *Player) Next() error {
-	return dbusCall(p.bus, cmdNext)
+	if err := p.dbusCall(cmdNext); err != nil {
+		return err
+	}
+	p.stepChapter(1)
+	return nil
 }
 
 // Previous tells the player to skip to the previous chapter. See
 // https://github.com/popcornmix/omxplayer#previous for more details.
 func (p *Player) Previous() error {
-	return dbusCall(p.bus, cmdPrevious)
+	if err := p.dbusCall(cmdPrevious); err != nil {
+		return err
+	}
+	p.stepChapter(-1)
+	return nil
 }
 
 // Pause pauses the player if it is playing. Otherwise, it resumes playback. See
 // https://github.com/popcornmix/omxplayer#pause for more details.
 func (p *Player) Pause() error {
-	return dbusCall(p.bus, cmdPause)
+	return p.dbusCall(cmdPause)
 }
 
 // Play play the video. If the video is playing, it has no effect,
-// if it is paused it will play from current position.
-// See https://github.com/popcornmix/omxplayer#play for more details.
+// if it is paused it will play from current position. CurrentSpeedIndex is
+// reset to zero. See https://github.com/popcornmix/omxplayer#play for more details.
 func (p *Player) Play() error {
-	return dbusCall(p.bus, cmdPlay)
+	if err := p.dbusCall(cmdPlay); err != nil {
+		return err
+	}
+	p.resetSpeed()
+	return nil
 }
 
 // PlayPause pauses the player if it is playing. Otherwise, it resumes playback.
 // See https://github.com/popcornmix/omxplayer#playpause for more details.
 func (p *Player) PlayPause() error {
-	return dbusCall(p.bus, cmdPlayPause)
+	return p.dbusCall(cmdPlayPause)
 }
 
 // Stop tells the player to stop playing the video. See
 // https://github.com/popcornmix/omxplayer#stop for more details.
 func (p *Player) Stop() error {
-	return dbusCall(p.bus, cmdStop)
+	return p.dbusCall(cmdStop)
 }
 
 // Seek performs a relative seek from the current video position. See
 // https://github.com/popcornmix/omxplayer#seek for more details.
 func (p *Player) Seek(amount int64) (int64, error) {
-	log.WithFields(log.Fields{
-		"path":        cmdSeek,
-		"paramAmount": amount,
-	}).Debug("omxplayer: dbus call")
-	call := p.bus.Call(cmdSeek, 0, amount)
+	p.debugf("omxplayer: dbus call %s amount=%d", cmdSeek, amount)
+	call := p.call(cmdSeek, amount)
 	if call.Err != nil {
 		return 0, call.Err
 	}
@@ -263,140 +556,159 @@ func (p *Player) Seek(amount int64) (int64, error) {
 // SetPosition performs an absolute seek to the specified video position. See
 // https://github.com/popcornmix/omxplayer#setposition for more details.
 func (p *Player) SetPosition(path string, position int64) (int64, error) {
-	log.WithFields(log.Fields{
-		"path":          cmdSetPosition,
-		"paramPath":     path,
-		"paramPosition": position,
-	}).Debug("omxplayer: dbus call")
-	call := p.bus.Call(cmdSetPosition, 0, dbus.ObjectPath(path), position)
+	p.debugf("omxplayer: dbus call %s path=%s position=%d", cmdSetPosition, path, position)
+	call := p.call(cmdSetPosition, dbus.ObjectPath(path), position)
 	if call.Err != nil {
 		return 0, call.Err
 	}
+	if position == 0 {
+		p.setChapter(0)
+	}
 	return call.Body[0].(int64), nil
 }
 
 // PlaybackStatus returns the current state of the player. See
 // https://github.com/popcornmix/omxplayer#playbackstatus for more details.
 func (p *Player) PlaybackStatus() (string, error) {
-	return dbusGetString(p.bus, propPlaybackStatus)
+	return p.dbusGetString(propPlaybackStatus)
 }
 
 // Volume returns the current volume. Sets a new volume when an argument is
 // specified. See https://github.com/popcornmix/omxplayer#volume for more
-// details.
+// details. If the player was launched with WithPassthrough, setting the volume
+// returns ErrPassthrough.
 func (p *Player) Volume(volume ...float64) (float64, error) {
-	log.WithFields(log.Fields{
-		"path":        cmdVolume,
-		"paramVolume": volume,
-	}).Debug("omxplayer: dbus call")
+	p.debugf("omxplayer: dbus call %s volume=%v", cmdVolume, volume)
 	if len(volume) == 0 {
-		return dbusGetFloat64(p.bus, cmdVolume)
+		return p.dbusGetFloat64(cmdVolume)
+	}
+	if p.config.passthrough {
+		return 0, ErrPassthrough
 	}
-	call := p.bus.Call(cmdVolume, 0, volume[0])
+	call := p.call(cmdVolume, volume[0])
 	if call.Err != nil {
 		return 0, call.Err
 	}
-	return call.Body[0].(float64), nil
+	applied, err := float64Body(cmdVolume, call.Body)
+	if err == nil {
+		p.recordVolume(applied)
+	}
+	return applied, err
 }
 
-// Mute mutes the video's audio stream. See
+// Mute mutes the video's audio stream. omxplayer keeps the volume while the
+// audio is muted, so Unmute plays it at the same volume again. See
 // https://github.com/popcornmix/omxplayer#mute for more details.
 func (p *Player) Mute() error {
-	return dbusCall(p.bus, cmdMute)
+	if err := p.dbusCall(cmdMute); err != nil {
+		return err
+	}
+	p.recordMute()
+	return nil
 }
 
-// Unmute unmutes the video's audio stream. See
-// https://github.com/popcornmix/omxplayer#unmute for more details.
+// Unmute unmutes the video's audio stream. If the volume was set while the audio
+// was muted, it is applied again once the audio is unmuted, so that it takes
+// effect even with omxplayer builds that do not apply volume changes while
+// muted. The audio is unmuted even if that fails, so the failure is only
+// logged. See https://github.com/popcornmix/omxplayer#unmute for more details.
 func (p *Player) Unmute() error {
-	return dbusCall(p.bus, cmdUnmute)
+	if err := p.dbusCall(cmdUnmute); err != nil {
+		return err
+	}
+	volume, changed := p.recordUnmute()
+	if !changed {
+		return nil
+	}
+	if _, err := p.Volume(volume); err != nil && !errors.Is(err, ErrPassthrough) {
+		p.debugf("omxplayer: failed to apply volume after unmuting: volume=%v error=%v", volume, err)
+	}
+	return nil
 }
 
-// Position returns the current position in the video in milliseconds. See
+// Position returns the current position in the video in microseconds. See
 // https://github.com/popcornmix/omxplayer#position for more details.
 func (p *Player) Position() (int64, error) {
-	return dbusGetInt64(p.bus, propPosition)
+	return p.dbusGetInt64(propPosition)
 }
 
 // Aspect returns the aspect ratio. See
 // https://github.com/popcornmix/omxplayer/blob/master/OMXControl.cpp#L362.
 func (p *Player) Aspect() (float64, error) {
-	return dbusGetFloat64(p.bus, propAspect)
+	return p.dbusGetFloat64(propAspect)
 }
 
 // VideoStreamCount returns the number of available video streams. See
 // https://github.com/popcornmix/omxplayer/blob/master/OMXControl.cpp#L369.
 func (p *Player) VideoStreamCount() (int64, error) {
-	return dbusGetInt64(p.bus, propVideoStreamCount)
+	return p.dbusGetInt64(propVideoStreamCount)
 }
 
 // ResWidth returns the width of the video. See
 // https://github.com/popcornmix/omxplayer/blob/master/OMXControl.cpp#L376.
 func (p *Player) ResWidth() (int64, error) {
-	return dbusGetInt64(p.bus, propResWidth)
+	return p.dbusGetInt64(propResWidth)
 }
 
 // ResHeight returns the height of the video. See
 // https://github.com/popcornmix/omxplayer/blob/master/OMXControl.cpp#L383.
 func (p *Player) ResHeight() (int64, error) {
-	return dbusGetInt64(p.bus, propResHeight)
+	return p.dbusGetInt64(propResHeight)
 }
 
-// Duration returns the total length of the video in milliseconds. See
+// Duration returns the total length of the video in microseconds. See
 // https://github.com/popcornmix/omxplayer#duration for more details.
 func (p *Player) Duration() (int64, error) {
-	return dbusGetInt64(p.bus, propDuration)
+	return p.dbusGetInt64(propDuration)
 }
 
 // MinimumRate returns the minimum playback rate. See
 // https://github.com/popcornmix/omxplayer#minimumrate for more details.
 func (p *Player) MinimumRate() (float64, error) {
-	return dbusGetFloat64(p.bus, propMinimumRate)
+	return p.dbusGetFloat64(propMinimumRate)
 }
 
 // MaximumRate returns the maximum playback rate. See
 // https://github.com/popcornmix/omxplayer#maximumrate for more details.
 func (p *Player) MaximumRate() (float64, error) {
-	return dbusGetFloat64(p.bus, propMaximumRate)
+	return p.dbusGetFloat64(propMaximumRate)
 }
 
 // ListSubtitles returns a list of the subtitles available in the video file.
 // See https://github.com/popcornmix/omxplayer#listsubtitles for more details.
 func (p *Player) ListSubtitles() ([]string, error) {
-	return dbusGetStringArray(p.bus, cmdListSubtitles)
+	return p.dbusGetStringArray(cmdListSubtitles)
 }
 
 // HideVideo is an undocumented D-Bus method. See
 // https://github.com/popcornmix/omxplayer/blob/master/OMXControl.cpp#L457.
 func (p *Player) HideVideo() error {
-	return dbusCall(p.bus, cmdHideVideo)
+	return p.dbusCall(cmdHideVideo)
 }
 
 // UnHideVideo is an undocumented D-Bus method. See
 // https://github.com/popcornmix/omxplayer/blob/master/OMXControl.cpp#L462.
 func (p *Player) UnHideVideo() error {
-	return dbusCall(p.bus, cmdUnHideVideo)
+	return p.dbusCall(cmdUnHideVideo)
 }
 
 // ListAudio returns a list of the audio tracks available in the video file. See
 // https://github.com/popcornmix/omxplayer#listaudio for more details.
 func (p *Player) ListAudio() ([]string, error) {
-	return dbusGetStringArray(p.bus, cmdListAudio)
+	return p.dbusGetStringArray(cmdListAudio)
 }
 
 // ListVideo returns a list of the video tracks available in the video file. See
 // https://github.com/popcornmix/omxplayer#listvideo for more details.
 func (p *Player) ListVideo() ([]string, error) {
-	return dbusGetStringArray(p.bus, cmdListVideo)
+	return p.dbusGetStringArray(cmdListVideo)
 }
 
 // SelectSubtitle specifies which subtitle track should be used. See
 // https://github.com/popcornmix/omxplayer#selectsubtitle for more details.
 func (p *Player) SelectSubtitle(index int32) (bool, error) {
-	log.WithFields(log.Fields{
-		"path":       cmdSelectSubtitle,
-		"paramIndex": index,
-	}).Debug("omxplayer: dbus call")
-	call := p.bus.Call(cmdSelectSubtitle, 0, index)
+	p.debugf("omxplayer: dbus call %s index=%d", cmdSelectSubtitle, index)
+	call := p.call(cmdSelectSubtitle, index)
 	if call.Err != nil {
 		return false, call.Err
 	}
@@ -406,11 +718,8 @@ func (p *Player) SelectSubtitle(index int32) (bool, error) {
 // SelectAudio specifies which audio track should be used. See
 // https://github.com/popcornmix/omxplayer#selectaudio for more details.
 func (p *Player) SelectAudio(index int32) (bool, error) {
-	log.WithFields(log.Fields{
-		"path":       cmdSelectAudio,
-		"paramIndex": index,
-	}).Debug("omxplayer: dbus call")
-	call := p.bus.Call(cmdSelectAudio, 0, index)
+	p.debugf("omxplayer: dbus call %s index=%d", cmdSelectAudio, index)
+	call := p.call(cmdSelectAudio, index)
 	if call.Err != nil {
 		return false, call.Err
 	}
@@ -420,21 +729,201 @@ func (p *Player) SelectAudio(index int32) (bool, error) {
 // ShowSubtitles starts displaying subtitles. See
 // https://github.com/popcornmix/omxplayer#showsubtitles for more details.
 func (p *Player) ShowSubtitles() error {
-	return dbusCall(p.bus, cmdShowSubtitles)
+	if err := p.dbusCall(cmdShowSubtitles); err != nil {
+		return err
+	}
+	p.setSubtitlesVisible(true)
+	return nil
 }
 
 // HideSubtitles stops displaying subtitles. See
 // https://github.com/popcornmix/omxplayer#hidesubtitles for more details.
 func (p *Player) HideSubtitles() error {
-	return dbusCall(p.bus, cmdHideSubtitles)
+	if err := p.dbusCall(cmdHideSubtitles); err != nil {
+		return err
+	}
+	p.setSubtitlesVisible(false)
+	return nil
 }
 
-// Action allows for executing keyboard commands. See
+// Action allows for executing keyboard commands, identified by one of the
+// Action constants, such as ActionIncreaseVolume. Actions are sent over D-Bus,
+// so they work even if the player was launched with WithNoKeys. See
 // https://github.com/popcornmix/omxplayer#action for more details.
 func (p *Player) Action(action int32) error {
-	log.WithFields(log.Fields{
-		"path":        cmdAction,
-		"paramAction": action,
-	}).Debug("omxplayer: dbus call")
-	return p.bus.Call(cmdAction, 0, action).Err
+	p.debugf("omxplayer: dbus call %s action=%d", cmdAction, action)
+	return p.call(cmdAction, action).Err
+}
+
+// SetVideoPos moves the video to the rectangle with the specified corners while
+// it is playing. The call only waits for omxplayer to acknowledge the request,
+// so it can be called repeatedly to animate the window. An error wrapping
+// ErrInvalidRect is returned if the coordinates are negative or do not describe
+// a rectangle. See https://github.com/popcornmix/omxplayer#setvideopos for more
+// details.
+func (p *Player) SetVideoPos(x1, y1, x2, y2 int) error {
+	rect := Rect{X1: x1, Y1: y1, X2: x2, Y2: y2}
+	if err := rect.validate(); err != nil {
+		return err
+	}
+
+	pos := fmt.Sprintf("%d %d %d %d", x1, y1, x2, y2)
+	p.debugf("omxplayer: dbus call %s pos=%s", cmdSetVideoPos, pos)
+	if err := p.call(cmdSetVideoPos, dbus.ObjectPath(pathMpris), pos).Err; err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.videoPos = &rect
+	return nil
+}
+
+// SetVideoCrop crops the video to the rectangle with the specified corners,
+// given in pixels of the source video, while it is playing. Combined with
+// SetVideoPos, this can be used to zoom into and pan around the video. An error
+// wrapping ErrInvalidRect is returned if the coordinates are negative or do not
+// describe a rectangle, or if omxplayer rejects them. See
+// https://github.com/popcornmix/omxplayer#setvideocroppos for more details.
+func (p *Player) SetVideoCrop(x1, y1, x2, y2 int64) error {
+	if err := validateRect(x1, y1, x2, y2); err != nil {
+		return err
+	}
+
+	crop := fmt.Sprintf("%d %d %d %d", x1, y1, x2, y2)
+	p.debugf("omxplayer: dbus call %s crop=%s", cmdSetVideoCropPos, crop)
+	err := p.call(cmdSetVideoCropPos, dbus.ObjectPath(pathMpris), crop).Err
+	if isDBusError(err, errInvalidArgs) {
+		return &wrappedError{ErrInvalidRect, err}
+	}
+	return err
+}
+
+// VideoPos returns the rectangle the video is played in. omxplayer cannot
+// report it, so this is the rectangle last passed to SetVideoPos, or else the
+// one requested with WithWindow. An error is returned if neither was set, in
+// which case the video is played fullscreen.
+func (p *Player) VideoPos() (Rect, error) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	if p.videoPos != nil {
+		return *p.videoPos, nil
+	}
+	if p.config.window != nil {
+		return *p.config.window, nil
+	}
+	return Rect{}, fmt.Errorf("omxplayer: video position is not known, the video is played fullscreen")
+}
+
+// SetAlpha sets the opacity of the video while it is playing, from 0 for fully
+// transparent to 255 for fully opaque, and returns the opacity that was applied.
+// Values outside that range are clamped to it. This works whether or not the
+// player was launched with WithAlpha. If the omxplayer build does not support
+// changing the opacity, an error wrapping ErrUnsupportedMethod is returned. See
+// https://github.com/popcornmix/omxplayer#setalpha for more details.
+func (p *Player) SetAlpha(alpha int64) (int64, error) {
+	if alpha < 0 {
+		alpha = 0
+	} else if alpha > 255 {
+		alpha = 255
+	}
+
+	p.debugf("omxplayer: dbus call %s alpha=%d", cmdSetAlpha, alpha)
+	call := p.call(cmdSetAlpha, dbus.ObjectPath(pathMpris), alpha)
+	if call.Err != nil {
+		return 0, call.Err
+	}
+	if len(call.Body) > 0 {
+		if applied, ok := call.Body[0].(int64); ok {
+			alpha = applied
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	applied := uint8(alpha)
+	p.alpha = &applied
+	return alpha, nil
+}
+
+// SetLayer moves the video to the specified dispmanx layer while it is playing,
+// for example to change which of several players is displayed on top. Negative
+// layers are valid. See https://github.com/popcornmix/omxplayer#setlayer for more
+// details.
+func (p *Player) SetLayer(layer int64) error {
+	p.debugf("omxplayer: dbus call %s layer=%d", cmdSetLayer, layer)
+	if err := p.call(cmdSetLayer, dbus.ObjectPath(pathMpris), layer).Err; err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	applied := int(layer)
+	p.layer = &applied
+	return nil
+}
+
+// SetAspectMode changes how the video is fitted to the screen while it is
+// playing. An error is returned if the mode is not supported. See
+// https://github.com/popcornmix/omxplayer#setaspectmode for more details.
+func (p *Player) SetAspectMode(mode AspectMode) error {
+	if err := mode.validate(); err != nil {
+		return err
+	}
+
+	p.debugf("omxplayer: dbus call %s mode=%s", cmdSetAspectMode, mode)
+	if err := p.call(cmdSetAspectMode, dbus.ObjectPath(pathMpris), string(mode)).Err; err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.aspectMode = mode
+	return nil
+}
+
+// GetSource returns the file or URL the player is playing. See
+// https://github.com/popcornmix/omxplayer#getsource for more details.
+func (p *Player) GetSource() (string, error) {
+	return p.dbusGetString(cmdGetSource)
+}
+
+// Source returns the file or URL the player is playing, like GetSource. If the
+// omxplayer build does not support GetSource, the URL the player was launched
+// with is returned instead.
+func (p *Player) Source() (string, error) {
+	source, err := p.GetSource()
+	if isUnknownMethod(err) {
+		p.mu.RLock()
+		defer p.mu.RUnlock()
+		return p.url, nil
+	}
+	return source, err
+}
+
+// OpenURI replaces the video being played with the one at the specified URL
+// without restarting the omxplayer process, which avoids the delay of launching
+// a new one. Source reports the new URL afterwards. If the omxplayer build does
+// not support it, an error wrapping ErrUnsupportedMethod is returned and a new
+// Player should be launched instead.
+func (p *Player) OpenURI(uri string) error {
+	p.debugf("omxplayer: dbus call %s uri=%s", cmdOpenURI, uri)
+	if err := p.call(cmdOpenURI, uri).Err; err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.resetMedia(uri)
+	return nil
+}
+
+// resetMedia records that the player switched to the video at the specified URL
+// and forgets the state tracked for the previous video. It must be called with
+// p.mu held.
+func (p *Player) resetMedia(url string) {
+	p.url = url
+	p.chapter = 0
+	p.subtitlesVisible = nil
 }
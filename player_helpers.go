@@ -1,40 +1,149 @@
 package omxplayer
 
 import (
+	"errors"
+	"fmt"
+
 	dbus "github.com/godbus/dbus/v5"
-	log "github.com/sirupsen/logrus"
 )
 
+const (
+	errUnknownMethod = "org.freedesktop.DBus.Error.UnknownMethod"
+	errInvalidArgs   = "org.freedesktop.DBus.Error.InvalidArgs"
+)
+
+// caller is implemented by the D-Bus object of an omxplayer instance. Player
+// only depends on this method so that the object can be replaced, for example
+// by a fake in tests.
+type caller interface {
+	Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call
+}
+
+// object returns the D-Bus object of the omxplayer instance. The object is
+// replaced when the connection is re-established, so it must always be obtained
+// through this method.
+func (p *Player) object() caller {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.bus
+}
+
+// removePIDFile removes the PID file written for the player, if any.
+func (p *Player) removePIDFile() {
+	if p.config.pidFile != "" {
+		removeFile(p.config.pidFile)
+	}
+}
+
+// call calls the D-Bus method at path with the specified arguments. If the call
+// fails because the omxplayer build does not implement the method, the error is
+// wrapped so that it matches ErrUnsupportedMethod, and if it fails because the
+// player is not ready to accept D-Bus commands yet, so that it matches
+// ErrNotReady.
+func (p *Player) call(path string, args ...interface{}) *dbus.Call {
+	call := p.object().Call(path, 0, args...)
+	if call.Err != nil && p.shouldReconnect(call.Err) {
+		p.debugf("omxplayer: dbus connection lost, reconnecting: error=%v", call.Err)
+		if err := p.Reconnect(); err == nil {
+			call = p.object().Call(path, 0, args...)
+		}
+	}
+
+	switch {
+	case call.Err == nil:
+	case isUnknownMethod(call.Err):
+		call.Err = &wrappedError{ErrUnsupportedMethod, call.Err}
+	case !p.IsReady():
+		call.Err = &wrappedError{ErrNotReady, call.Err}
+	}
+	return call
+}
+
+// shouldReconnect returns true if the player was configured to reconnect
+// automatically and err indicates that the connection to a player that had
+// been ready was lost.
+func (p *Player) shouldReconnect(err error) bool {
+	if !p.config.autoReconnect || !connectionLost(err) {
+		return false
+	}
+
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.ready
+}
+
+// isUnknownMethod returns true if err is the D-Bus error returned when calling
+// a method the omxplayer build does not implement.
+func isUnknownMethod(err error) bool {
+	return isDBusError(err, errUnknownMethod)
+}
+
+// isDBusError returns true if err is a D-Bus error with the specified name.
+func isDBusError(err error, name string) bool {
+	var dbusErr dbus.Error
+	return errors.As(err, &dbusErr) && dbusErr.Name == name
+}
+
 // dbusCall calls a D-Bus method that has no return value.
-func dbusCall(bus *dbus.Object, path string) error {
-	log.WithFields(log.Fields{"path": path}).Debug("omxplayer: dbus call")
-	return bus.Call(path, 0).Err
+func (p *Player) dbusCall(path string) error {
+	p.debugf("omxplayer: dbus call %s", path)
+	return p.call(path).Err
 }
 
 // dbusGetBool calls a D-Bus method that will return a boolean value.
-func dbusGetBool(bus *dbus.Object, path string) (bool, error) {
-	log.WithFields(log.Fields{"path": path}).Debug("omxplayer: dbus call")
-	call := bus.Call(path, 0)
+func (p *Player) dbusGetBool(path string) (bool, error) {
+	p.debugf("omxplayer: dbus call %s", path)
+	call := p.call(path)
 	if call.Err != nil {
 		return false, call.Err
 	}
 	return call.Body[0].(bool), nil
 }
 
-// dbusGetFloat64 calls a D-Bus method that will return an int64 value.
-func dbusGetFloat64(bus *dbus.Object, path string) (float64, error) {
-	log.WithFields(log.Fields{"path": path}).Debug("omxplayer: dbus call")
-	call := bus.Call(path, 0)
+// dbusGetFloat64 calls a D-Bus method that will return a float64 value.
+func (p *Player) dbusGetFloat64(path string) (float64, error) {
+	p.debugf("omxplayer: dbus call %s", path)
+	call := p.call(path)
 	if call.Err != nil {
 		return 0, call.Err
 	}
-	return call.Body[0].(float64), nil
+	return float64Body(path, call.Body)
+}
+
+// float64Body returns the first value of a D-Bus reply body as a float64. Some
+// omxplayer and D-Bus combinations reply with an integer or a variant instead
+// of a double, so those are converted rather than causing a panic.
+func float64Body(path string, body []interface{}) (float64, error) {
+	if len(body) == 0 {
+		return 0, fmt.Errorf("omxplayer: empty reply from %s", path)
+	}
+
+	value := body[0]
+	if variant, ok := value.(dbus.Variant); ok {
+		value = variant.Value()
+	}
+
+	switch v := value.(type) {
+	case float64:
+		return v, nil
+	case float32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case uint64:
+		return float64(v), nil
+	case uint32:
+		return float64(v), nil
+	}
+	return 0, fmt.Errorf("omxplayer: unexpected %T reply from %s", value, path)
 }
 
 // dbusGetInt64 calls a D-Bus method that will return an int64 value.
-func dbusGetInt64(bus *dbus.Object, path string) (int64, error) {
-	log.WithFields(log.Fields{"path": path}).Debug("omxplayer: dbus call")
-	call := bus.Call(path, 0)
+func (p *Player) dbusGetInt64(path string) (int64, error) {
+	p.debugf("omxplayer: dbus call %s", path)
+	call := p.call(path)
 	if call.Err != nil {
 		return 0, call.Err
 	}
@@ -42,9 +151,9 @@ func dbusGetInt64(bus *dbus.Object, path string) (int64, error) {
 }
 
 // dbusGetString calls a D-Bus method that will return a string value.
-func dbusGetString(bus *dbus.Object, path string) (string, error) {
-	log.WithFields(log.Fields{"path": path}).Debug("omxplayer: dbus call")
-	call := bus.Call(path, 0)
+func (p *Player) dbusGetString(path string) (string, error) {
+	p.debugf("omxplayer: dbus call %s", path)
+	call := p.call(path)
 	if call.Err != nil {
 		return "", call.Err
 	}
@@ -52,9 +161,9 @@ func dbusGetString(bus *dbus.Object, path string) (string, error) {
 }
 
 // dbusGetStringArray calls a D-Bus method that will return a string array.
-func dbusGetStringArray(bus *dbus.Object, path string) ([]string, error) {
-	log.WithFields(log.Fields{"path": path}).Debug("omxplayer: dbus call")
-	call := bus.Call(path, 0)
+func (p *Player) dbusGetStringArray(path string) ([]string, error) {
+	p.debugf("omxplayer: dbus call %s", path)
+	call := p.call(path)
 	if call.Err != nil {
 		return nil, call.Err
 	}
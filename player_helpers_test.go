@@ -0,0 +1,307 @@
+package omxplayer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// fakeCall is a call received by a fakeCaller.
+type fakeCall struct {
+	method string
+	args   []interface{}
+}
+
+// fakeCaller is a caller that replies to calls with scripted responses and
+// records the calls it receives. A call is answered by the first of the
+// following that is set for its method: a queued reply, a handler or a fixed
+// response. Calls to any other method fail with an UnknownMethod error, like
+// they do when omxplayer does not implement a method.
+type fakeCaller struct {
+	mu        sync.Mutex
+	responses map[string]*dbus.Call
+	queued    map[string][]*dbus.Call
+	handlers  map[string]func(args []interface{}) *dbus.Call
+	calls     []fakeCall
+}
+
+// newFakeCaller returns a fakeCaller without any responses.
+func newFakeCaller() *fakeCaller {
+	return &fakeCaller{
+		responses: make(map[string]*dbus.Call),
+		queued:    make(map[string][]*dbus.Call),
+		handlers:  make(map[string]func(args []interface{}) *dbus.Call),
+	}
+}
+
+// respond makes every call to method succeed with the specified reply body.
+func (f *fakeCaller) respond(method string, body ...interface{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[method] = &dbus.Call{Body: body}
+}
+
+// fail makes every call to method fail with err.
+func (f *fakeCaller) fail(method string, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.responses[method] = &dbus.Call{Err: err}
+}
+
+// queue makes the next calls to method return the specified replies, in
+// order, before any handler or fixed response is used.
+func (f *fakeCaller) queue(method string, replies ...*dbus.Call) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.queued[method] = append(f.queued[method], replies...)
+}
+
+// handle makes calls to method reply with the result of fn, which is called
+// with the arguments of the call while the fakeCaller is locked.
+func (f *fakeCaller) handle(method string, fn func(args []interface{}) *dbus.Call) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.handlers[method] = fn
+}
+
+// handleVolume makes the fakeCaller keep a volume, starting at the specified
+// one, that the Volume property reports and sets like omxplayer does.
+func (f *fakeCaller) handleVolume(volume float64) {
+	f.handle(cmdVolume, func(args []interface{}) *dbus.Call {
+		if len(args) > 0 {
+			volume = args[0].(float64)
+		}
+		return &dbus.Call{Body: []interface{}{volume}}
+	})
+}
+
+// Call records the call and returns a copy of its scripted reply, so that
+// callers that modify the reply do not affect later calls.
+func (f *fakeCaller) Call(method string, flags dbus.Flags, args ...interface{}) *dbus.Call {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fakeCall{method: method, args: args})
+
+	reply, ok := f.responses[method]
+	if queued := f.queued[method]; len(queued) > 0 {
+		reply, f.queued[method] = queued[0], queued[1:]
+	} else if handler := f.handlers[method]; handler != nil {
+		reply = handler(args)
+	} else if !ok {
+		reply = &dbus.Call{Err: dbus.Error{Name: errUnknownMethod}}
+	}
+	return &dbus.Call{Method: method, Args: args, Body: reply.Body, Err: reply.Err}
+}
+
+// callsTo returns the arguments of every call made to method, in order.
+func (f *fakeCaller) callsTo(method string) [][]interface{} {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var calls [][]interface{}
+	for _, call := range f.calls {
+		if call.method == method {
+			calls = append(calls, call.args)
+		}
+	}
+	return calls
+}
+
+// actions returns the actions sent with the Action method, in order.
+func (f *fakeCaller) actions() []int32 {
+	var actions []int32
+	for _, args := range f.callsTo(cmdAction) {
+		actions = append(actions, args[0].(int32))
+	}
+	return actions
+}
+
+// newTestPlayer returns a ready Player, configured with the specified options,
+// that calls omxplayer through bus instead of a D-Bus connection.
+func newTestPlayer(t *testing.T, bus caller, opts ...Option) *Player {
+	t.Helper()
+
+	config := &Config{}
+	for _, opt := range opts {
+		if err := opt(config); err != nil {
+			t.Fatalf("applying option: %v", err)
+		}
+	}
+	return &Player{
+		bus:    bus,
+		config: config,
+		output: newProcessOutput(config),
+		exited: make(chan struct{}),
+		ready:  true,
+	}
+}
+
+// replaceDial replaces the function Reconnect dials omxplayer with until
+// restore is called. Every dial succeeds and connects to bus, and dials counts
+// them.
+func replaceDial(bus caller) (dials *int, restore func()) {
+	dials = new(int)
+	original := dial
+	dial = func(*Player) (*dbus.Conn, caller, error) {
+		*dials++
+		return nil, bus, nil
+	}
+	return dials, func() { dial = original }
+}
+
+func TestCallUnknownMethod(t *testing.T) {
+	p := newTestPlayer(t, newFakeCaller())
+
+	err := p.call(cmdGetSource).Err
+	if !errors.Is(err, ErrUnsupportedMethod) {
+		t.Fatalf("got error %v, want ErrUnsupportedMethod", err)
+	}
+	var dbusErr dbus.Error
+	if !errors.As(err, &dbusErr) || dbusErr.Name != errUnknownMethod {
+		t.Errorf("got error %v, want it to wrap the %s error", err, errUnknownMethod)
+	}
+}
+
+func TestCallNotReady(t *testing.T) {
+	bus := newFakeCaller()
+	bus.fail(propCanQuit, dbus.Error{Name: errServiceUnknown})
+	bus.fail(propPosition, dbus.Error{Name: errServiceUnknown})
+	p := newTestPlayer(t, bus)
+	p.ready = false
+
+	if _, err := p.Position(); !errors.Is(err, ErrNotReady) {
+		t.Errorf("got error %v, want ErrNotReady", err)
+	}
+}
+
+func TestCallReadyFailure(t *testing.T) {
+	failure := dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"}
+	bus := newFakeCaller()
+	bus.fail(propPosition, failure)
+	p := newTestPlayer(t, bus)
+
+	_, err := p.Position()
+	if errors.Is(err, ErrNotReady) || errors.Is(err, ErrUnsupportedMethod) {
+		t.Errorf("got error %v, want the unwrapped D-Bus error", err)
+	}
+	if dbusErr, ok := err.(dbus.Error); !ok || dbusErr.Name != failure.Name {
+		t.Errorf("got error %v, want %v", err, failure)
+	}
+}
+
+func TestCallReconnects(t *testing.T) {
+	dropped := newFakeCaller()
+	dropped.fail(propPosition, dbus.ErrClosed)
+	restored := newFakeCaller()
+	restored.respond(propPosition, int64(5000000))
+	dials, restore := replaceDial(restored)
+	defer restore()
+	p := newTestPlayer(t, dropped, WithAutoReconnect())
+
+	position, err := p.Position()
+	if err != nil {
+		t.Fatalf("got error %v, want the call to be retried", err)
+	}
+	if position != 5000000 {
+		t.Errorf("got position %d, want 5000000", position)
+	}
+	if *dials != 1 {
+		t.Errorf("dialled %d times, want 1", *dials)
+	}
+	if n := len(restored.callsTo(propPosition)); n != 1 {
+		t.Errorf("got %d calls on the new connection, want 1", n)
+	}
+}
+
+func TestCallReconnectDisabled(t *testing.T) {
+	bus := newFakeCaller()
+	bus.fail(propPosition, dbus.ErrClosed)
+	dials, restore := replaceDial(newFakeCaller())
+	defer restore()
+	p := newTestPlayer(t, bus)
+
+	if _, err := p.Position(); !errors.Is(err, dbus.ErrClosed) {
+		t.Errorf("got error %v, want dbus.ErrClosed", err)
+	}
+	if *dials != 0 {
+		t.Errorf("dialled %d times without WithAutoReconnect, want 0", *dials)
+	}
+}
+
+func TestCallReconnectNotReady(t *testing.T) {
+	bus := newFakeCaller()
+	bus.fail(propCanQuit, dbus.ErrClosed)
+	bus.fail(propPosition, dbus.ErrClosed)
+	dials, restore := replaceDial(newFakeCaller())
+	defer restore()
+	p := newTestPlayer(t, bus, WithAutoReconnect())
+	p.ready = false
+
+	if _, err := p.Position(); !errors.Is(err, ErrNotReady) {
+		t.Errorf("got error %v, want ErrNotReady", err)
+	}
+	if *dials != 0 {
+		t.Errorf("dialled %d times before the player was ready, want 0", *dials)
+	}
+}
+
+func TestFloat64Body(t *testing.T) {
+	tests := []struct {
+		body []interface{}
+		want float64
+	}{
+		{[]interface{}{0.75}, 0.75},
+		{[]interface{}{float32(0.5)}, 0.5},
+		{[]interface{}{int64(1)}, 1},
+		{[]interface{}{int32(2)}, 2},
+		{[]interface{}{uint64(3)}, 3},
+		{[]interface{}{uint32(4)}, 4},
+		{[]interface{}{dbus.MakeVariant(0.25)}, 0.25},
+		{[]interface{}{dbus.MakeVariant(int64(1))}, 1},
+	}
+	for _, tt := range tests {
+		got, err := float64Body(cmdVolume, tt.body)
+		if err != nil {
+			t.Errorf("float64Body(%#v) returned error: %v", tt.body, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("float64Body(%#v) = %v, want %v", tt.body, got, tt.want)
+		}
+	}
+
+	for _, body := range [][]interface{}{nil, {"1.0"}, {true}, {dbus.MakeVariant("1.0")}} {
+		if _, err := float64Body(cmdVolume, body); err == nil {
+			t.Errorf("float64Body(%#v) returned no error", body)
+		}
+	}
+}
+
+func TestVolumeReplyTypes(t *testing.T) {
+	bus := newFakeCaller()
+	p := newTestPlayer(t, bus)
+
+	bus.respond(cmdVolume, int64(1))
+	if volume, err := p.Volume(); err != nil || volume != 1 {
+		t.Errorf("got %v, %v for an integer reply, want 1, nil", volume, err)
+	}
+	if volume, err := p.Volume(1); err != nil || volume != 1 {
+		t.Errorf("got %v, %v for an integer reply to a write, want 1, nil", volume, err)
+	}
+
+	bus.respond(cmdVolume, dbus.MakeVariant(0.5))
+	if volume, err := p.Volume(); err != nil || volume != 0.5 {
+		t.Errorf("got %v, %v for a variant reply, want 0.5, nil", volume, err)
+	}
+
+	bus.respond(cmdVolume, "loud")
+	if _, err := p.Volume(); err == nil {
+		t.Error("got no error for a string reply")
+	}
+	bus.respond(cmdVolume)
+	if _, err := p.Volume(0.5); err == nil {
+		t.Error("got no error for an empty reply")
+	}
+}
@@ -0,0 +1,356 @@
+package omxplayer
+
+import (
+	"context"
+	"errors"
+	"os/exec"
+	"sync"
+	"testing"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+func TestSetVideoCrop(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdSetVideoCropPos, "10 20 110 220")
+	p := newTestPlayer(t, bus)
+
+	if err := p.SetVideoCrop(10, 20, 110, 220); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := bus.callsTo(cmdSetVideoCropPos)
+	if len(calls) != 1 {
+		t.Fatalf("got %d calls, want 1", len(calls))
+	}
+	if path, ok := calls[0][0].(dbus.ObjectPath); !ok || path != pathMpris {
+		t.Errorf("got path argument %#v, want %s", calls[0][0], pathMpris)
+	}
+	if crop, ok := calls[0][1].(string); !ok || crop != "10 20 110 220" {
+		t.Errorf("got crop argument %#v, want \"10 20 110 220\"", calls[0][1])
+	}
+}
+
+func TestSetVideoCropInvalid(t *testing.T) {
+	bus := newFakeCaller()
+	bus.fail(cmdSetVideoCropPos, dbus.Error{Name: errInvalidArgs})
+	p := newTestPlayer(t, bus)
+
+	if err := p.SetVideoCrop(-1, 0, 100, 100); !errors.Is(err, ErrInvalidRect) {
+		t.Errorf("got error %v for a negative coordinate, want ErrInvalidRect", err)
+	}
+	if err := p.SetVideoCrop(100, 0, 50, 100); !errors.Is(err, ErrInvalidRect) {
+		t.Errorf("got error %v for an empty rectangle, want ErrInvalidRect", err)
+	}
+	if n := len(bus.callsTo(cmdSetVideoCropPos)); n != 0 {
+		t.Errorf("invalid rectangles were sent to omxplayer %d times", n)
+	}
+
+	if err := p.SetVideoCrop(0, 0, 4000, 4000); !errors.Is(err, ErrInvalidRect) {
+		t.Errorf("got error %v for a rejected rectangle, want ErrInvalidRect", err)
+	}
+}
+
+func TestIsReady(t *testing.T) {
+	notReady := dbus.Error{Name: errServiceUnknown}
+	bus := newFakeCaller()
+	bus.queue(propCanQuit, &dbus.Call{Err: notReady}, &dbus.Call{Body: []interface{}{false}})
+	bus.respond(propCanQuit, true)
+	p := newTestPlayer(t, bus)
+	p.ready = false
+
+	for i, want := range []bool{false, false, true, true} {
+		if got := p.IsReady(); got != want {
+			t.Errorf("call %d: got %t, want %t", i+1, got, want)
+		}
+	}
+	if n := len(bus.callsTo(propCanQuit)); n != 3 {
+		t.Errorf("got %d CanQuit calls, want 3 since readiness is remembered", n)
+	}
+}
+
+func TestWaitForReady(t *testing.T) {
+	notReady := &dbus.Call{Err: dbus.Error{Name: errServiceUnknown}}
+	bus := newFakeCaller()
+	bus.queue(propCanQuit, notReady, notReady, notReady)
+	bus.respond(propCanQuit, true)
+	p := newTestPlayer(t, bus)
+	p.ready = false
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := p.WaitForReadyContext(ctx); err != nil {
+		t.Fatalf("returned error: %v", err)
+	}
+	if n := len(bus.callsTo(propCanQuit)); n != 4 {
+		t.Errorf("got %d CanQuit calls, want 4", n)
+	}
+}
+
+func TestWaitForReadyCancelled(t *testing.T) {
+	bus := newFakeCaller()
+	bus.fail(propCanQuit, dbus.Error{Name: errServiceUnknown})
+	p := newTestPlayer(t, bus)
+	p.ready = false
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if err := p.WaitForReadyContext(ctx); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want context.DeadlineExceeded", err)
+	}
+	if p.IsReady() {
+		t.Error("player is ready although CanQuit always failed")
+	}
+}
+
+func TestIsRunning(t *testing.T) {
+	p := newTestPlayer(t, newFakeCaller())
+	if p.IsRunning() {
+		t.Error("got true before a process was started")
+	}
+
+	p.command = exec.Command("sleep", "10")
+	if err := p.command.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	if !p.IsRunning() {
+		t.Error("got false while the process is running")
+	}
+
+	p.command.Process.Kill()
+	p.command.Wait()
+	if p.IsRunning() {
+		t.Error("got true after the process exited")
+	}
+}
+
+// TestConcurrentCalls calls the player from several goroutines while its
+// connection is replaced, so that go test -race reports unguarded state.
+func TestConcurrentCalls(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(propPosition, int64(1000000))
+	bus.respond(propCanQuit, true)
+	bus.handleVolume(1)
+	original := dial
+	dial = func(*Player) (*dbus.Conn, caller, error) {
+		return nil, bus, nil
+	}
+	defer func() { dial = original }()
+	p := newTestPlayer(t, bus)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(4)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := p.Position(); err != nil {
+					t.Errorf("Position returned error: %v", err)
+					return
+				}
+			}
+		}()
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if _, err := p.Volume(float64(i) / 4); err != nil {
+					t.Errorf("Volume returned error: %v", err)
+					return
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				p.IsReady()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				if err := p.Reconnect(); err != nil {
+					t.Errorf("Reconnect returned error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestSource(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdGetSource, "/media/other.mp4")
+	p := newTestPlayer(t, bus)
+	p.url = "/media/video.mp4"
+
+	if source, err := p.Source(); err != nil || source != "/media/other.mp4" {
+		t.Errorf("got %q, %v, want the source reported over D-Bus", source, err)
+	}
+
+	failure := dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"}
+	bus.fail(cmdGetSource, failure)
+	if _, err := p.Source(); err == nil {
+		t.Error("returned no error when GetSource failed")
+	}
+}
+
+func TestSourceFallback(t *testing.T) {
+	bus := newFakeCaller()
+	p := newTestPlayer(t, bus)
+	p.url = "/media/video.mp4"
+
+	if source, err := p.Source(); err != nil || source != "/media/video.mp4" {
+		t.Errorf("got %q, %v, want the launch URL", source, err)
+	}
+	if n := len(bus.callsTo(cmdGetSource)); n != 1 {
+		t.Errorf("got %d GetSource calls, want 1", n)
+	}
+}
+
+func TestSetLayer(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdSetLayer)
+	p := newTestPlayer(t, bus, WithLayer(1))
+
+	if err := p.SetLayer(-3); err != nil {
+		t.Fatal(err)
+	}
+	calls := bus.callsTo(cmdSetLayer)
+	if len(calls) != 1 {
+		t.Fatalf("got %d SetLayer calls, want 1", len(calls))
+	}
+	if len(calls[0]) != 2 {
+		t.Fatalf("got arguments %#v, want a path and a layer", calls[0])
+	}
+	if path, ok := calls[0][0].(dbus.ObjectPath); !ok || path != pathMpris {
+		t.Errorf("got path %#v, want dbus.ObjectPath(%q)", calls[0][0], pathMpris)
+	}
+	if layer, ok := calls[0][1].(int64); !ok || layer != -3 {
+		t.Errorf("got layer %#v, want int64(-3)", calls[0][1])
+	}
+	if layer := p.Layer(); layer != -3 {
+		t.Errorf("got Layer %d after SetLayer, want -3", layer)
+	}
+
+	bus.fail(cmdSetLayer, dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"})
+	if err := p.SetLayer(5); err == nil {
+		t.Error("returned no error when the call failed")
+	}
+	if layer := p.Layer(); layer != -3 {
+		t.Errorf("got Layer %d after a failed SetLayer, want -3", layer)
+	}
+}
+
+func TestOpenURI(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdOpenURI)
+	bus.respond(cmdListSubtitles, []string{"0:eng:English:subrip:"})
+	p := newTestPlayer(t, bus)
+	visible := true
+	p.url = "/media/video.mp4"
+	p.chapter = 2
+	p.subtitlesVisible = &visible
+
+	if err := p.OpenURI("/media/other.mp4"); err != nil {
+		t.Fatal(err)
+	}
+	if calls := bus.callsTo(cmdOpenURI); len(calls) != 1 || calls[0][0] != "/media/other.mp4" {
+		t.Errorf("got OpenUri calls %v, want one with the new URI", calls)
+	}
+	if source, err := p.Source(); err != nil || source != "/media/other.mp4" {
+		t.Errorf("got source %q, %v, want the new URI", source, err)
+	}
+	if chapter := p.CurrentChapter(); chapter != 0 {
+		t.Errorf("got chapter %d, want 0", chapter)
+	}
+	if visible, err := p.SubtitlesVisible(); err != nil || visible {
+		t.Errorf("got subtitles visible %t, %v, want the state of the new video", visible, err)
+	}
+	if n := len(bus.callsTo(cmdListSubtitles)); n != 1 {
+		t.Errorf("got %d ListSubtitles calls, want 1 since the cached state was cleared", n)
+	}
+}
+
+func TestOpenURIUnsupported(t *testing.T) {
+	p := newTestPlayer(t, newFakeCaller())
+	p.url = "/media/video.mp4"
+	p.chapter = 2
+
+	if err := p.OpenURI("/media/other.mp4"); !errors.Is(err, ErrUnsupportedMethod) {
+		t.Errorf("got error %v, want ErrUnsupportedMethod", err)
+	}
+	if p.url != "/media/video.mp4" || p.CurrentChapter() != 2 {
+		t.Errorf("got url %q and chapter %d, want the state to be kept", p.url, p.CurrentChapter())
+	}
+}
+
+func TestCanSeek(t *testing.T) {
+	if propCanSeek != "org.freedesktop.DBus.Properties.CanSeek" {
+		t.Errorf("got property %q, want org.freedesktop.DBus.Properties.CanSeek", propCanSeek)
+	}
+
+	for _, want := range []bool{true, false} {
+		bus := newFakeCaller()
+		bus.respond(propCanSeek, want)
+		p := newTestPlayer(t, bus)
+
+		if got, err := p.CanSeek(); err != nil || got != want {
+			t.Errorf("got %t, %v, want %t, nil", got, err, want)
+		}
+		if n := len(bus.callsTo(propCanSeek)); n != 1 {
+			t.Errorf("got %d CanSeek calls, want 1", n)
+		}
+		if n := len(bus.callsTo(cmdSeek)); n != 0 {
+			t.Errorf("got %d Seek calls, want 0", n)
+		}
+	}
+}
+
+// startSleep starts a sleep process for p that does not react to Quit, and
+// reaps it like New does.
+func startSleep(t *testing.T, p *Player) {
+	t.Helper()
+
+	p.command = exec.Command("sleep", "60")
+	if err := p.command.Start(); err != nil {
+		t.Skipf("cannot start sleep: %v", err)
+	}
+	go p.reap()
+}
+
+func TestCloseKillsHungProcess(t *testing.T) {
+	original := closeTimeout
+	closeTimeout = 50 * time.Millisecond
+	defer func() { closeTimeout = original }()
+
+	for _, quit := range []error{nil, dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"}} {
+		bus := newFakeCaller()
+		if quit == nil {
+			bus.respond(cmdQuit)
+		} else {
+			bus.fail(cmdQuit, quit)
+		}
+		p := newTestPlayer(t, bus)
+		startSleep(t, p)
+
+		closed := make(chan error)
+		go func() { closed <- p.Close() }()
+		select {
+		case err := <-closed:
+			if err != nil {
+				t.Errorf("quit error %v: Close returned error: %v", quit, err)
+			}
+		case <-time.After(5 * time.Second):
+			p.Kill()
+			t.Fatalf("quit error %v: Close blocked on a process that ignores Quit", quit)
+		}
+		if p.IsRunning() {
+			t.Errorf("quit error %v: process is still running after Close", quit)
+		}
+		if n := len(bus.callsTo(cmdQuit)); n != 1 {
+			t.Errorf("quit error %v: got %d Quit calls, want 1", quit, n)
+		}
+	}
+}
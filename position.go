@@ -0,0 +1,241 @@
+package omxplayer
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultChapterDelay is the pause between the chapter skips made by
+// SeekChapter, unless another one is set with WithChapterDelay.
+const defaultChapterDelay = 100 * time.Millisecond
+
+// PositionDuration returns the current position in the video as a
+// time.Duration.
+func (p *Player) PositionDuration() (time.Duration, error) {
+	position, err := p.Position()
+	return fromMicroseconds(position), err
+}
+
+// DurationTime returns the total length of the video as a time.Duration.
+func (p *Player) DurationTime() (time.Duration, error) {
+	duration, err := p.Duration()
+	return fromMicroseconds(duration), err
+}
+
+// SeekTo performs an absolute seek to the specified position and returns the
+// position reported by the player. Negative positions are treated as the start
+// of the video, and an error is returned if pos is past the end of the video.
+func (p *Player) SeekTo(pos time.Duration) (time.Duration, error) {
+	if pos < 0 {
+		pos = 0
+	}
+
+	duration, err := p.DurationTime()
+	if err != nil {
+		return 0, err
+	}
+	if pos > duration {
+		return 0, fmt.Errorf("omxplayer: position %s is past the end of the video (%s)", pos, duration)
+	}
+
+	position, err := p.SetPosition(pathMpris, toMicroseconds(pos))
+	return fromMicroseconds(position), err
+}
+
+// SeekToPercent performs an absolute seek to the specified fraction of the
+// video, from 0 for the start to 1 for the end, and returns the position
+// reported by the player. The duration is read from the player on every call.
+// An error is returned if pct is not between 0 and 1.
+func (p *Player) SeekToPercent(pct float64) (time.Duration, error) {
+	if !(pct >= 0 && pct <= 1) {
+		return 0, fmt.Errorf("omxplayer: seek fraction must be between 0 and 1: %v", pct)
+	}
+
+	duration, err := p.DurationTime()
+	if err != nil {
+		return 0, err
+	}
+	return p.SeekTo(time.Duration(pct * float64(duration)))
+}
+
+// SeekRelative seeks forwards, or backwards if delta is negative, from the
+// current position and returns the position that was seeked to. Seeking back
+// past the start of the video seeks to the start, matching omxplayer's own
+// behaviour, rather than returning an error.
+func (p *Player) SeekRelative(delta time.Duration) (time.Duration, error) {
+	position, err := p.PositionDuration()
+	if err != nil {
+		return 0, err
+	}
+
+	if position+delta < 0 {
+		return p.SeekTo(0)
+	}
+	if _, err = p.Seek(toMicroseconds(delta)); err != nil {
+		return 0, err
+	}
+	return position + delta, nil
+}
+
+// fromMicroseconds converts a number of microseconds, as used by omxplayer, to a
+// time.Duration.
+func fromMicroseconds(us int64) time.Duration {
+	return time.Duration(us) * time.Microsecond
+}
+
+// toMicroseconds converts a time.Duration to the number of microseconds used by
+// omxplayer.
+func toMicroseconds(d time.Duration) int64 {
+	return int64(d / time.Microsecond)
+}
+
+// DriftBetween returns how far player a is ahead of player b. The positions of
+// both players are read concurrently so that they are sampled as close together
+// as possible. A negative result means that a is behind b.
+func DriftBetween(a, b *Player) (time.Duration, error) {
+	type result struct {
+		position int64
+		err      error
+	}
+
+	results := make(chan result, 1)
+	go func() {
+		position, err := b.Position()
+		results <- result{position, err}
+	}()
+
+	posA, errA := a.Position()
+	resB := <-results
+	if errA != nil {
+		return 0, errA
+	}
+	if resB.err != nil {
+		return 0, resB.err
+	}
+	return fromMicroseconds(posA - resB.position), nil
+}
+
+// SeekToChapter jumps to the chapter with the specified zero-based index.
+// omxplayer does not publish chapter start times over D-Bus, so rather than
+// seeking to a timestamp, the player is rewound to the start of the video and
+// then skipped forward one chapter at a time using SeekChapter, which waits
+// between skips so that omxplayer does not drop any.
+func (p *Player) SeekToChapter(index int) error {
+	if index < 0 {
+		return fmt.Errorf("omxplayer: invalid chapter index: %d", index)
+	}
+
+	if _, err := p.SetPosition(pathMpris, 0); err != nil {
+		return err
+	}
+	if index > 0 {
+		time.Sleep(p.chapterDelay())
+	}
+	return p.SeekChapter(index)
+}
+
+// Progress returns how far playback has progressed through the video, from 0 at
+// the start to 1 at the end. If the duration is not known yet, 0 is returned.
+// The result is clamped to 1, since the position can briefly exceed the
+// reported duration.
+func (p *Player) Progress() (float64, error) {
+	position, err := p.Position()
+	if err != nil {
+		return 0, err
+	}
+	duration, err := p.Duration()
+	if err != nil {
+		return 0, err
+	}
+
+	if duration <= 0 || position <= 0 {
+		return 0, nil
+	}
+	if position >= duration {
+		return 1, nil
+	}
+	return float64(position) / float64(duration), nil
+}
+
+// Chapter describes a chapter of the video.
+type Chapter struct {
+	Title string
+	Start time.Duration
+}
+
+// ListChapters is meant to return the chapters of the video. No omxplayer
+// release publishes chapter titles or start times over D-Bus, or through the
+// track lists and metadata it does publish, so ListChapters always returns
+// ErrUnsupported. Use SeekToChapter or the ActionNextChapter and
+// ActionPreviousChapter actions to move between chapters instead.
+func (p *Player) ListChapters() ([]Chapter, error) {
+	return nil, ErrUnsupported
+}
+
+// CurrentChapter returns the zero-based index of the current chapter. omxplayer
+// does not report it, so it is tracked from the calls made through this Player:
+// Next and NextChapter move to the next chapter, Previous and PreviousChapter to
+// the previous one, and seeking to the start of the video with SetPosition
+// returns to the first chapter. Chapters reached by playing through the video
+// or by other seeks are not taken into account.
+func (p *Player) CurrentChapter() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.chapter
+}
+
+// SeekChapter moves from the chapter reported by CurrentChapter to the chapter
+// with the specified zero-based index by calling Next or Previous as many times
+// as needed, waiting for the delay set with WithChapterDelay between calls. If
+// a call fails, SeekChapter stops and returns an error that reports the chapter
+// reached, which CurrentChapter also reports.
+func (p *Player) SeekChapter(n int) error {
+	if n < 0 {
+		return fmt.Errorf("omxplayer: invalid chapter index: %d", n)
+	}
+
+	step := p.Next
+	delta := n - p.CurrentChapter()
+	if delta < 0 {
+		step, delta = p.Previous, -delta
+	}
+
+	delay := p.chapterDelay()
+	for i := 0; i < delta; i++ {
+		if i > 0 {
+			time.Sleep(delay)
+		}
+		if err := step(); err != nil {
+			return fmt.Errorf("omxplayer: stopped at chapter %d: %w", p.CurrentChapter(), err)
+		}
+	}
+	return nil
+}
+
+// chapterDelay returns how long to wait between chapter skips.
+func (p *Player) chapterDelay() time.Duration {
+	if p.config.chapterDelay != 0 {
+		return p.config.chapterDelay
+	}
+	return defaultChapterDelay
+}
+
+// stepChapter records a move of delta chapters. The chapter index does not go
+// below zero, since omxplayer restarts the first chapter when skipping back
+// from it.
+func (p *Player) stepChapter(delta int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.chapter += delta
+	if p.chapter < 0 {
+		p.chapter = 0
+	}
+}
+
+// setChapter records that the player is at the chapter with the specified
+// index.
+func (p *Player) setChapter(chapter int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.chapter = chapter
+}
@@ -0,0 +1,122 @@
+package omxplayer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// recordTimes makes calls to method succeed and returns a function that
+// reports when each call was made.
+func recordTimes(bus *fakeCaller, method string) func() []time.Time {
+	var mu sync.Mutex
+	var times []time.Time
+	bus.handle(method, func([]interface{}) *dbus.Call {
+		mu.Lock()
+		defer mu.Unlock()
+		times = append(times, time.Now())
+		return &dbus.Call{}
+	})
+	return func() []time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]time.Time(nil), times...)
+	}
+}
+
+func TestSeekToChapter(t *testing.T) {
+	const delay = 20 * time.Millisecond
+
+	bus := newFakeCaller()
+	bus.respond(cmdSetPosition, int64(0))
+	nexts := recordTimes(bus, cmdNext)
+	p := newTestPlayer(t, bus, WithChapterDelay(delay))
+	p.chapter = 5
+
+	start := time.Now()
+	if err := p.SeekToChapter(3); err != nil {
+		t.Fatal(err)
+	}
+
+	if calls := bus.callsTo(cmdSetPosition); len(calls) != 1 || calls[0][1] != int64(0) {
+		t.Errorf("got SetPosition calls %v, want a single seek to the start", calls)
+	}
+
+	times := nexts()
+	if len(times) != 3 {
+		t.Fatalf("got %d calls to Next, want 3", len(times))
+	}
+	previous := start
+	for i, at := range times {
+		if gap := at.Sub(previous); gap < delay {
+			t.Errorf("skip %d was made %s after the previous call, want at least %s", i, gap, delay)
+		}
+		previous = at
+	}
+	if chapter := p.CurrentChapter(); chapter != 3 {
+		t.Errorf("got chapter %d, want 3", chapter)
+	}
+}
+
+func TestSeekToChapterStart(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdSetPosition, int64(0))
+	p := newTestPlayer(t, bus)
+
+	if err := p.SeekToChapter(0); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(bus.callsTo(cmdNext)); n != 0 {
+		t.Errorf("got %d calls to Next, want none", n)
+	}
+	if err := p.SeekToChapter(-1); err == nil {
+		t.Error("got no error for a negative chapter")
+	}
+}
+
+func TestPositionDuration(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(propPosition, int64(83250000))
+	bus.respond(propDuration, int64(3723500000))
+	p := newTestPlayer(t, bus)
+
+	if position, err := p.PositionDuration(); err != nil || position != 83250*time.Millisecond {
+		t.Errorf("got position %s, %v, want 1m23.25s, nil", position, err)
+	}
+	if duration, err := p.DurationTime(); err != nil || duration != time.Hour+2*time.Minute+3500*time.Millisecond {
+		t.Errorf("got duration %s, %v, want 1h2m3.5s, nil", duration, err)
+	}
+
+	bus.fail(propPosition, dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"})
+	if position, err := p.PositionDuration(); err == nil || position != 0 {
+		t.Errorf("got position %s, %v, want 0 and an error", position, err)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	tests := []struct {
+		position, duration int64
+		want               float64
+	}{
+		{0, 10000000, 0},
+		{2500000, 10000000, 0.25},
+		{5000000, 10000000, 0.5},
+		{10000000, 10000000, 1},
+		{10500000, 10000000, 1},
+		{-1000, 10000000, 0},
+		{5000000, 0, 0},
+		{5000000, -1, 0},
+	}
+	for _, tt := range tests {
+		bus := newFakeCaller()
+		bus.respond(propPosition, tt.position)
+		bus.respond(propDuration, tt.duration)
+		p := newTestPlayer(t, bus)
+
+		if got, err := p.Progress(); err != nil || got != tt.want {
+			t.Errorf("position %d of %d: got %v, %v, want %v, nil", tt.position, tt.duration, got, err, tt.want)
+		}
+	}
+}
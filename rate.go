@@ -0,0 +1,190 @@
+package omxplayer
+
+import (
+	"errors"
+	"fmt"
+)
+
+const propRate = ifaceProps + ".Rate"
+
+// speedSteps lists the playback rates omxplayer steps through when its speed is
+// increased or decreased with the 1 and 2 keys, and speedNormal is the index of
+// normal speed within it.
+var speedSteps = []float64{1.0 / 16, 1.0 / 8, 1.0 / 4, 1.0 / 2, 0.975, 1, 1.125}
+
+const speedNormal = 5
+
+// maxScanSteps is the number of times FastForward or Rewind escalate the speed,
+// from twice up to 32 times normal speed, before further calls have no effect.
+const maxScanSteps = 5
+
+// Rate returns the current playback rate, where 1.0 is normal speed. See
+// https://github.com/popcornmix/omxplayer#rate for more details.
+func (p *Player) Rate() (float64, error) {
+	return p.dbusGetFloat64(propRate)
+}
+
+// SetRate changes the playback rate and returns the rate the player applied,
+// which may differ from the requested one because omxplayer only supports a
+// fixed set of rates. An error wrapping ErrRateOutOfRange is returned, without
+// changing the rate, if rate is outside the range reported by MinimumRate and
+// MaximumRate.
+func (p *Player) SetRate(rate float64) (float64, error) {
+	min, err := p.MinimumRate()
+	if err != nil {
+		return 0, err
+	}
+	max, err := p.MaximumRate()
+	if err != nil {
+		return 0, err
+	}
+	if rate < min || rate > max {
+		return 0, fmt.Errorf("%w: %v is not between %v and %v", ErrRateOutOfRange, rate, min, max)
+	}
+
+	p.debugf("omxplayer: dbus call %s rate=%v", propRate, rate)
+	call := p.call(propRate, rate)
+	if call.Err != nil {
+		return 0, call.Err
+	}
+	return float64Body(propRate, call.Body)
+}
+
+// FastForward speeds up playback like the > key does. The first call plays the
+// video at twice its normal speed, and repeated calls escalate the speed further,
+// in the same steps as pressing the key repeatedly. If the video is being
+// rewound, FastForward slows down the rewinding first.
+func (p *Player) FastForward() error {
+	return p.scan(1, ActionFastForward)
+}
+
+// Rewind plays the video backwards like the < key does. The first call rewinds
+// at twice the normal speed, and repeated calls escalate the speed further, in
+// the same steps as pressing the key repeatedly. If the video is being fast
+// forwarded, Rewind slows it down first.
+func (p *Player) Rewind() error {
+	return p.scan(-1, ActionRewind)
+}
+
+// scan sends the FastForward or Rewind action and records that it changed the
+// speed by delta steps, up to maxScanSteps in either direction.
+func (p *Player) scan(delta int, action int32) error {
+	if err := p.Action(action); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if index := p.scanIndex + delta; index >= -maxScanSteps && index <= maxScanSteps {
+		p.scanIndex = index
+	}
+	return nil
+}
+
+// NormalSpeed resets the playback rate to normal speed after FastForward,
+// Rewind, SetRate, SpeedUp or SlowDown. If the omxplayer build does not support
+// setting the rate, the steps taken by FastForward and Rewind are undone with
+// the opposite action, and those reported by CurrentSpeedIndex with the
+// ActionDecreaseSpeed or ActionIncreaseSpeed action, the way SpeedUp and
+// SlowDown fall back to them. Like CurrentSpeedIndex, the fallback only knows
+// about the steps taken through this Player.
+func (p *Player) NormalSpeed() error {
+	_, err := p.SetRate(1)
+	if errors.Is(err, ErrUnsupportedMethod) {
+		if err = p.undoSteps(&p.scanIndex, ActionFastForward, ActionRewind); err != nil {
+			return err
+		}
+		return p.undoSteps(&p.speedIndex, ActionIncreaseSpeed, ActionDecreaseSpeed)
+	}
+	if err != nil {
+		return err
+	}
+	p.resetSpeed()
+	return nil
+}
+
+// undoSteps returns to normal speed by sending the action opposite to the one
+// the speed was changed with, once for every step recorded in index: decrease
+// while index is positive and increase while it is negative. index must point
+// to a field guarded by p.mu, and is updated after every action.
+func (p *Player) undoSteps(index *int, increase, decrease int32) error {
+	p.mu.RLock()
+	steps := *index
+	p.mu.RUnlock()
+
+	for steps != 0 {
+		action, delta := decrease, -1
+		if steps < 0 {
+			action, delta = increase, 1
+		}
+		if err := p.Action(action); err != nil {
+			return err
+		}
+		steps += delta
+
+		p.mu.Lock()
+		*index = steps
+		p.mu.Unlock()
+	}
+	return nil
+}
+
+// SpeedUp increases the playback speed by one step, like the 2 key does, up to
+// 1.125 times normal speed. The rate is set through SetRate and, if the
+// omxplayer build does not support that, with the ActionIncreaseSpeed action.
+// Some omxplayer builds reset the speed when seeking.
+func (p *Player) SpeedUp() error {
+	return p.stepSpeed(1, ActionIncreaseSpeed)
+}
+
+// SlowDown decreases the playback speed by one step, like the 1 key does, down
+// to a sixteenth of normal speed, in the same way SpeedUp increases it.
+func (p *Player) SlowDown() error {
+	return p.stepSpeed(-1, ActionDecreaseSpeed)
+}
+
+// CurrentSpeedIndex returns the number of steps SpeedUp and SlowDown have
+// changed the speed by since the player was launched or last played at normal
+// speed with Play or NormalSpeed: positive when faster and negative when slower
+// than normal. omxplayer does not report the steps, so speed changes made by
+// other means are not taken into account.
+func (p *Player) CurrentSpeedIndex() int {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.speedIndex
+}
+
+// stepSpeed changes the playback speed by delta steps, falling back to the
+// specified action if the rate cannot be set directly. The speed is not changed
+// beyond the fastest or slowest step.
+func (p *Player) stepSpeed(delta int, action int32) error {
+	index := p.CurrentSpeedIndex() + delta
+	if speedNormal+index < 0 || speedNormal+index >= len(speedSteps) {
+		return nil
+	}
+
+	_, err := p.SetRate(speedSteps[speedNormal+index])
+	if errors.Is(err, ErrUnsupportedMethod) {
+		err = p.Action(action)
+	}
+	if err != nil {
+		return err
+	}
+	p.setSpeedIndex(index)
+	return nil
+}
+
+// setSpeedIndex records the number of steps the speed has been changed by.
+func (p *Player) setSpeedIndex(index int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.speedIndex = index
+}
+
+// resetSpeed records that the video is playing at normal speed.
+func (p *Player) resetSpeed() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.speedIndex = 0
+	p.scanIndex = 0
+}
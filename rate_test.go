@@ -0,0 +1,185 @@
+package omxplayer
+
+import (
+	"errors"
+	"testing"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// newRateCaller returns a fakeCaller that reports omxplayer's rate limits and,
+// if rate is true, supports setting the rate.
+func newRateCaller(rate bool) *fakeCaller {
+	bus := newFakeCaller()
+	bus.respond(propMinimumRate, 0.0625)
+	bus.respond(propMaximumRate, 4.0)
+	bus.respond(cmdAction)
+	if rate {
+		bus.handle(propRate, func(args []interface{}) *dbus.Call {
+			return &dbus.Call{Body: args}
+		})
+	}
+	return bus
+}
+
+// equalActions returns true if a and b hold the same actions in the same order.
+func equalActions(a, b []int32) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSetRate(t *testing.T) {
+	bus := newRateCaller(true)
+	p := newTestPlayer(t, bus)
+
+	for _, rate := range []float64{0.0625, 1, 4} {
+		if applied, err := p.SetRate(rate); err != nil || applied != rate {
+			t.Errorf("SetRate(%v) = %v, %v, want %v, nil", rate, applied, err, rate)
+		}
+	}
+
+	bus.handle(propRate, func([]interface{}) *dbus.Call {
+		return &dbus.Call{Body: []interface{}{2.0}}
+	})
+	if applied, err := p.SetRate(1.9); err != nil || applied != 2 {
+		t.Errorf("got %v, %v, want the applied rate 2, nil", applied, err)
+	}
+}
+
+func TestSetRateOutOfRange(t *testing.T) {
+	bus := newRateCaller(true)
+	p := newTestPlayer(t, bus)
+
+	for _, rate := range []float64{0.05, 4.5, -1} {
+		if _, err := p.SetRate(rate); !errors.Is(err, ErrRateOutOfRange) {
+			t.Errorf("SetRate(%v) returned error %v, want ErrRateOutOfRange", rate, err)
+		}
+	}
+	if calls := bus.callsTo(propRate); len(calls) != 0 {
+		t.Errorf("got %d Rate calls for rates out of range, want 0", len(calls))
+	}
+}
+
+func TestFastForwardRewind(t *testing.T) {
+	bus := newRateCaller(false)
+	p := newTestPlayer(t, bus)
+
+	if err := p.FastForward(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.FastForward(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Rewind(); err != nil {
+		t.Fatal(err)
+	}
+
+	want := []int32{ActionFastForward, ActionFastForward, ActionRewind}
+	if got := bus.actions(); !equalActions(got, want) {
+		t.Errorf("got actions %v, want %v", got, want)
+	}
+}
+
+func TestNormalSpeed(t *testing.T) {
+	bus := newRateCaller(true)
+	p := newTestPlayer(t, bus)
+
+	if err := p.SpeedUp(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.NormalSpeed(); err != nil {
+		t.Fatal(err)
+	}
+
+	calls := bus.callsTo(propRate)
+	if len(calls) != 2 || calls[1][0] != 1.0 {
+		t.Errorf("got rate calls %v, want the rate to be set to 1 last", calls)
+	}
+	if index := p.CurrentSpeedIndex(); index != 0 {
+		t.Errorf("got speed index %d, want 0", index)
+	}
+	if actions := bus.actions(); len(actions) != 0 {
+		t.Errorf("got actions %v, want none", actions)
+	}
+}
+
+func TestNormalSpeedFallback(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps func(p *Player) error
+		want  []int32
+	}{
+		{
+			name:  "faster",
+			steps: (*Player).SpeedUp,
+			want:  []int32{ActionIncreaseSpeed, ActionDecreaseSpeed},
+		},
+		{
+			name: "slower",
+			steps: func(p *Player) error {
+				if err := p.SlowDown(); err != nil {
+					return err
+				}
+				return p.SlowDown()
+			},
+			want: []int32{ActionDecreaseSpeed, ActionDecreaseSpeed, ActionIncreaseSpeed, ActionIncreaseSpeed},
+		},
+		{
+			name:  "normal",
+			steps: func(*Player) error { return nil },
+		},
+		{
+			name: "fast forward",
+			steps: func(p *Player) error {
+				if err := p.FastForward(); err != nil {
+					return err
+				}
+				return p.FastForward()
+			},
+			want: []int32{ActionFastForward, ActionFastForward, ActionRewind, ActionRewind},
+		},
+		{
+			name:  "rewind",
+			steps: (*Player).Rewind,
+			want:  []int32{ActionRewind, ActionFastForward},
+		},
+		{
+			name: "fast forward and slower",
+			steps: func(p *Player) error {
+				if err := p.FastForward(); err != nil {
+					return err
+				}
+				return p.SlowDown()
+			},
+			want: []int32{ActionFastForward, ActionDecreaseSpeed, ActionRewind, ActionIncreaseSpeed},
+		},
+	}
+
+	for _, tt := range tests {
+		bus := newRateCaller(false)
+		p := newTestPlayer(t, bus)
+
+		if err := tt.steps(p); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if err := p.NormalSpeed(); err != nil {
+			t.Fatalf("%s: got error %v, want the fallback to be used", tt.name, err)
+		}
+		if got := bus.actions(); !equalActions(got, tt.want) {
+			t.Errorf("%s: got actions %v, want %v", tt.name, got, tt.want)
+		}
+		if index := p.CurrentSpeedIndex(); index != 0 {
+			t.Errorf("%s: got speed index %d, want 0", tt.name, index)
+		}
+		if err := p.NormalSpeed(); err != nil || len(bus.actions()) != len(tt.want) {
+			t.Errorf("%s: got actions %v, %v after a second NormalSpeed, want no more", tt.name, bus.actions(), err)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package omxplayer
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const fileStreamFifo = "stream"
+
+// streamCloseTimeout is how long Close waits for the goroutine copying a stream
+// to finish after closing the FIFO, which only takes longer if the reader is
+// blocked in Read.
+const streamCloseTimeout = time.Second
+
+// NewFromReader returns a new Player instance that plays the video read from r.
+// The stream is copied into a FIFO that omxplayer reads from. When r reaches
+// EOF, the FIFO is closed so that omxplayer sees the end of the stream and can
+// finish cleanly. Call Close to stop the copy, close and remove the FIFO and
+// wait for the omxplayer process to finish.
+func NewFromReader(r io.Reader, opts ...Option) (player *Player, err error) {
+	dir, err := ioutil.TempDir("", "omxplayer")
+	if err != nil {
+		return
+	}
+
+	fifo := filepath.Join(dir, fileStreamFifo)
+	if err = makeFIFO(fifo); err != nil {
+		removeDir(dir)
+		return
+	}
+
+	stream := copyStream(fifo, r)
+	player, err = NewWithOptions(fifo, opts...)
+	if err != nil {
+		stream.close()
+		removeDir(dir)
+		return
+	}
+
+	player.stream = stream
+	return
+}
+
+// streamCopy copies a reader into a FIFO from a separate goroutine.
+type streamCopy struct {
+	path   string
+	opened chan struct{}
+	done   chan struct{}
+
+	mu     sync.Mutex
+	file   *os.File
+	closed bool
+}
+
+// copyStream starts copying r into the FIFO at the specified path.
+func copyStream(path string, r io.Reader) *streamCopy {
+	s := &streamCopy{
+		path:   path,
+		opened: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go s.run(r)
+	return s
+}
+
+// run copies r into the FIFO. Opening the FIFO blocks until omxplayer opens it
+// for reading. The write end is closed once r reaches EOF, the copy fails or
+// the copy is closed.
+func (s *streamCopy) run(r io.Reader) {
+	defer close(s.done)
+
+	f, err := os.OpenFile(s.path, os.O_WRONLY, 0)
+	close(s.opened)
+	if err != nil {
+		debugf("omxplayer: failed to open fifo: path=%s error=%v", s.path, err)
+		return
+	}
+
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+		f.Close()
+		return
+	}
+	s.file = f
+	s.mu.Unlock()
+	defer s.closeFile()
+
+	if _, err = io.Copy(f, r); err != nil {
+		debugf("omxplayer: failed to copy stream into fifo: path=%s error=%v", s.path, err)
+	}
+}
+
+// closeFile closes the write end of the FIFO, if it is open.
+func (s *streamCopy) closeFile() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+}
+
+// close stops the copy and closes the write end of the FIFO. A copy still
+// waiting for a reader is released first. If the reader is blocked in Read, the
+// copy only finishes once Read returns, so close waits for it for at most
+// streamCloseTimeout.
+func (s *streamCopy) close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	releaseFIFO(s.path, s.opened)
+	s.closeFile()
+
+	select {
+	case <-s.done:
+	case <-time.After(streamCloseTimeout):
+		debugf("omxplayer: stream reader is still blocked: path=%s", s.path)
+	}
+}
+
+// releaseFIFO unblocks an attempt to open the FIFO at the specified path for
+// writing that is still waiting for a reader, and waits until the attempt has
+// finished. It does so by briefly opening the read end of the FIFO until
+// opened is closed.
+func releaseFIFO(path string, opened <-chan struct{}) {
+	for {
+		if f, err := os.OpenFile(path, os.O_RDONLY|syscall.O_NONBLOCK, 0); err == nil {
+			f.Close()
+		}
+
+		select {
+		case <-opened:
+			return
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
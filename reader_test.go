@@ -0,0 +1,151 @@
+package omxplayer
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestFIFO creates a FIFO in a new temporary directory and returns its path
+// along with a function that removes the directory.
+func newTestFIFO(t *testing.T) (string, func()) {
+	t.Helper()
+
+	dir, err := ioutil.TempDir("", "omxplayer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, fileStreamFifo)
+	if err = makeFIFO(path); err != nil {
+		os.RemoveAll(dir)
+		t.Skipf("cannot create a fifo: %v", err)
+	}
+	return path, func() { os.RemoveAll(dir) }
+}
+
+// openFDs returns the number of file descriptors the process has open, or -1
+// if it cannot be determined.
+func openFDs() int {
+	fds, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(fds)
+}
+
+// waitForGoroutines waits for the number of goroutines to drop to n and returns
+// the number of goroutines that are running.
+func waitForGoroutines(n int) int {
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > n && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	return runtime.NumGoroutine()
+}
+
+// waitDone returns true if done is closed within d.
+func waitDone(done <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-done:
+		return true
+	default:
+	}
+
+	select {
+	case <-done:
+		return true
+	case <-time.After(d):
+		return false
+	}
+}
+
+func TestStreamCopyEOF(t *testing.T) {
+	path, cleanup := newTestFIFO(t)
+	defer cleanup()
+
+	goroutines, fds := runtime.NumGoroutine(), openFDs()
+
+	stream := copyStream(path, strings.NewReader("video data"))
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadAll(f)
+	f.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "video data" {
+		t.Errorf("read %q from the fifo, want %q", data, "video data")
+	}
+
+	if !waitDone(stream.done, time.Second) {
+		t.Error("copy did not finish when the reader reached EOF")
+	}
+	stream.close()
+
+	if n := waitForGoroutines(goroutines); n > goroutines {
+		t.Errorf("%d goroutines are running after close, want %d", n, goroutines)
+	}
+	if n := openFDs(); n > fds {
+		t.Errorf("%d file descriptors are open after close, want at most %d", n, fds)
+	}
+}
+
+func TestStreamCopyCloseWithoutReader(t *testing.T) {
+	path, cleanup := newTestFIFO(t)
+	defer cleanup()
+
+	goroutines, fds := runtime.NumGoroutine(), openFDs()
+
+	stream := copyStream(path, strings.NewReader("video data"))
+	stream.close()
+
+	if !waitDone(stream.done, 0) {
+		t.Error("copy was still waiting for a reader after close")
+	}
+	if n := waitForGoroutines(goroutines); n > goroutines {
+		t.Errorf("%d goroutines are running after close, want %d", n, goroutines)
+	}
+	if n := openFDs(); n > fds {
+		t.Errorf("%d file descriptors are open after close, want at most %d", n, fds)
+	}
+}
+
+func TestStreamCopyCloseBlockedReader(t *testing.T) {
+	path, cleanup := newTestFIFO(t)
+	defer cleanup()
+
+	r, w := io.Pipe()
+	defer w.Close()
+
+	stream := copyStream(path, r)
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	closed := make(chan struct{})
+	go func() {
+		stream.close()
+		close(closed)
+	}()
+	if !waitDone(closed, streamCloseTimeout+time.Second) {
+		t.Fatal("close did not return while the reader was blocked")
+	}
+
+	if data, err := ioutil.ReadAll(f); err != nil || len(data) != 0 {
+		t.Errorf("got %q, %v from the fifo, want it to be closed", data, err)
+	}
+
+	w.Close()
+	if !waitDone(stream.done, time.Second) {
+		t.Error("copy did not finish once the reader returned")
+	}
+}
@@ -0,0 +1,42 @@
+package omxplayer
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// supportedSchemes lists the URL schemes of the protocols omxplayer can open
+// through libavformat.
+var supportedSchemes = []string{
+	"file", "http", "https", "ftp", "hls",
+	"rtsp", "rtsps", "rtmp", "rtmps", "rtmpt", "rtp", "udp", "tcp",
+	"mms", "mmsh", "mmst",
+}
+
+// checkSource returns an error if source is a local file that does not exist,
+// or a URL that cannot be parsed or has a scheme omxplayer does not support.
+func checkSource(source string) error {
+	if !strings.Contains(source, "://") {
+		if _, err := os.Stat(source); err != nil {
+			return &wrappedError{ErrSourceNotFound, err}
+		}
+		return nil
+	}
+
+	u, err := url.Parse(source)
+	if err != nil {
+		return fmt.Errorf("omxplayer: invalid source url: %w", err)
+	}
+	scheme := strings.ToLower(u.Scheme)
+	for _, supported := range supportedSchemes {
+		if scheme == supported {
+			if scheme == "file" {
+				return checkSource(u.Path)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrUnsupportedScheme, source)
+}
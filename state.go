@@ -0,0 +1,115 @@
+package omxplayer
+
+// PlayerConfig is a snapshot of the player's state that can be captured and
+// applied again later, for example to restore a saved session. Nil fields are
+// unknown when captured and left untouched when applied. Position is in
+// microseconds.
+type PlayerConfig struct {
+	AudioTrack       *int32
+	SubtitleTrack    *int32
+	SubtitlesVisible *bool
+	Position         *int64
+	Volume           *float64
+	Muted            *bool
+}
+
+// Apply pushes every non-nil field of cfg to the player. Tracks are selected
+// first, followed by the subtitle visibility, position, volume and finally the
+// mute state. A failure does not stop the remaining fields from being applied;
+// all failures are returned together as a MultiError.
+func (p *Player) Apply(cfg PlayerConfig) error {
+	var errs MultiError
+	add := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if cfg.AudioTrack != nil {
+		_, err := p.SelectAudio(*cfg.AudioTrack)
+		add(err)
+	}
+	if cfg.SubtitleTrack != nil {
+		_, err := p.SelectSubtitle(*cfg.SubtitleTrack)
+		add(err)
+	}
+	if cfg.SubtitlesVisible != nil {
+		if *cfg.SubtitlesVisible {
+			add(p.ShowSubtitles())
+		} else {
+			add(p.HideSubtitles())
+		}
+	}
+	if cfg.Position != nil {
+		_, err := p.SetPosition(pathMpris, *cfg.Position)
+		add(err)
+	}
+	if cfg.Volume != nil {
+		_, err := p.Volume(*cfg.Volume)
+		add(err)
+	}
+	if cfg.Muted != nil {
+		if *cfg.Muted {
+			add(p.Mute())
+		} else {
+			add(p.Unmute())
+		}
+	}
+	return errs.errorOrNil()
+}
+
+// Capture reads the player's current state into a PlayerConfig. omxplayer does
+// not report whether it is muted or whether subtitles are visible, so those
+// fields are filled in from the state tracked by IsMuted and SubtitlesVisible.
+// Fields that could not be read are nil. Failures are returned together as a
+// MultiError alongside the fields that were read.
+func (p *Player) Capture() (PlayerConfig, error) {
+	var cfg PlayerConfig
+	var errs MultiError
+
+	if tracks, err := p.ListAudio(); err != nil {
+		errs = append(errs, err)
+	} else if index, ok := activeTrack(tracks); ok {
+		cfg.AudioTrack = &index
+	}
+
+	if tracks, err := p.ListSubtitles(); err != nil {
+		errs = append(errs, err)
+	} else if index, ok := activeTrack(tracks); ok {
+		cfg.SubtitleTrack = &index
+	}
+
+	if visible, err := p.SubtitlesVisible(); err != nil {
+		errs = append(errs, err)
+	} else {
+		cfg.SubtitlesVisible = &visible
+	}
+
+	if position, err := p.Position(); err != nil {
+		errs = append(errs, err)
+	} else {
+		cfg.Position = &position
+	}
+
+	if volume, err := p.Volume(); err != nil {
+		errs = append(errs, err)
+	} else {
+		cfg.Volume = &volume
+	}
+
+	muted := p.IsMuted()
+	cfg.Muted = &muted
+
+	return cfg, errs.errorOrNil()
+}
+
+// activeTrack returns the index of the track marked as active in a track list
+// returned by omxplayer, such as "0:eng:English:ac3:active".
+func activeTrack(tracks []string) (int32, bool) {
+	for _, track := range tracks {
+		if stream, err := parseStream(track); err == nil && stream.Active {
+			return stream.Index, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,81 @@
+package omxplayer
+
+import "testing"
+
+// newStateCaller returns a fakeCaller for a player with two audio tracks and a
+// visible subtitle track.
+func newStateCaller() *fakeCaller {
+	bus := newFakeCaller()
+	bus.respond(cmdListAudio, []string{"0:eng:English:ac3:", "1:ger:Deutsch:ac3:active"})
+	bus.respond(cmdListSubtitles, []string{"0:eng:English:subrip:active"})
+	bus.respond(propPosition, int64(42000000))
+	bus.handleVolume(0.5)
+	bus.respond(cmdMute)
+	bus.respond(cmdUnmute)
+	bus.respond(cmdShowSubtitles)
+	bus.respond(cmdHideSubtitles)
+	bus.respond(cmdSelectAudio, true)
+	bus.respond(cmdSelectSubtitle, true)
+	bus.respond(cmdSetPosition, int64(42000000))
+	return bus
+}
+
+func TestCaptureTrackedState(t *testing.T) {
+	p := newTestPlayer(t, newStateCaller())
+
+	cfg, err := p.Capture()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Muted == nil || *cfg.Muted {
+		t.Errorf("got Muted %v, want false", cfg.Muted)
+	}
+	if cfg.SubtitlesVisible == nil || !*cfg.SubtitlesVisible {
+		t.Errorf("got SubtitlesVisible %v, want true for an active subtitle track", cfg.SubtitlesVisible)
+	}
+
+	if err = p.Mute(); err != nil {
+		t.Fatal(err)
+	}
+	if err = p.HideSubtitles(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err = p.Capture(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Muted == nil || !*cfg.Muted {
+		t.Errorf("got Muted %v after Mute, want true", cfg.Muted)
+	}
+	if cfg.SubtitlesVisible == nil || *cfg.SubtitlesVisible {
+		t.Errorf("got SubtitlesVisible %v after HideSubtitles, want false", cfg.SubtitlesVisible)
+	}
+	if cfg.AudioTrack == nil || *cfg.AudioTrack != 1 {
+		t.Errorf("got AudioTrack %v, want 1", cfg.AudioTrack)
+	}
+}
+
+func TestApplyCapture(t *testing.T) {
+	source := newTestPlayer(t, newStateCaller())
+	if err := source.Mute(); err != nil {
+		t.Fatal(err)
+	}
+	if err := source.HideSubtitles(); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := source.Capture()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bus := newStateCaller()
+	target := newTestPlayer(t, bus)
+	if err = target.Apply(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if !target.IsMuted() {
+		t.Error("target is not muted after applying a muted state")
+	}
+	if n := len(bus.callsTo(cmdHideSubtitles)); n != 1 {
+		t.Errorf("got %d HideSubtitles calls, want 1", n)
+	}
+}
@@ -0,0 +1,108 @@
+package omxplayer
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Status is a snapshot of the information a status display typically needs,
+// gathered by the Status method.
+type Status struct {
+	PlaybackStatus string
+	Playing        bool
+	Position       time.Duration
+	Duration       time.Duration
+	Volume         float64
+	Width          int64
+	Height         int64
+	Aspect         float64
+}
+
+// Status fetches the playback status, position, duration, volume, resolution and
+// aspect ratio of the video in a single pass. A failure does not stop the other
+// fields from being fetched; the fields that could be fetched are returned along
+// with a MultiError holding every failure.
+func (p *Player) Status() (Status, error) {
+	var status Status
+	var errs MultiError
+	add := func(err error) {
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	var err error
+	status.PlaybackStatus, err = p.PlaybackStatus()
+	add(err)
+	status.Playing = status.PlaybackStatus == statusPlaying
+
+	status.Position, err = p.PositionDuration()
+	add(err)
+	status.Duration, err = p.DurationTime()
+	add(err)
+	status.Volume, err = p.Volume()
+	add(err)
+	status.Width, err = p.ResWidth()
+	add(err)
+	status.Height, err = p.ResHeight()
+	add(err)
+	status.Aspect, err = p.Aspect()
+	add(err)
+
+	return status, errs.errorOrNil()
+}
+
+// statusJSON is the JSON representation of a Status. Its field names must stay
+// stable, since clients depend on them.
+type statusJSON struct {
+	PlaybackStatus string  `json:"playbackStatus"`
+	Playing        bool    `json:"playing"`
+	Position       int64   `json:"positionMs"`
+	Duration       int64   `json:"durationMs"`
+	Volume         float64 `json:"volume"`
+	Width          int64   `json:"width"`
+	Height         int64   `json:"height"`
+	Aspect         float64 `json:"aspect"`
+}
+
+// MarshalJSON encodes s as JSON, with the position and duration in whole
+// milliseconds and the playback status in lowercase, for example "playing".
+func (s Status) MarshalJSON() ([]byte, error) {
+	return json.Marshal(statusJSON{
+		PlaybackStatus: strings.ToLower(s.PlaybackStatus),
+		Playing:        s.Playing,
+		Position:       int64(s.Position / time.Millisecond),
+		Duration:       int64(s.Duration / time.Millisecond),
+		Volume:         s.Volume,
+		Width:          s.Width,
+		Height:         s.Height,
+		Aspect:         s.Aspect,
+	})
+}
+
+// UnmarshalJSON decodes JSON produced by MarshalJSON into s, restoring the
+// capitalisation omxplayer uses for the playback status.
+func (s *Status) UnmarshalJSON(data []byte) error {
+	var v statusJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	status := v.PlaybackStatus
+	if status != "" {
+		status = strings.ToUpper(status[:1]) + status[1:]
+	}
+
+	*s = Status{
+		PlaybackStatus: status,
+		Playing:        v.Playing,
+		Position:       time.Duration(v.Position) * time.Millisecond,
+		Duration:       time.Duration(v.Duration) * time.Millisecond,
+		Volume:         v.Volume,
+		Width:          v.Width,
+		Height:         v.Height,
+		Aspect:         v.Aspect,
+	}
+	return nil
+}
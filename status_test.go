@@ -0,0 +1,52 @@
+package omxplayer
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestStatusJSON(t *testing.T) {
+	status := Status{
+		PlaybackStatus: statusPlaying,
+		Playing:        true,
+		Position:       83250*time.Millisecond + 400*time.Microsecond,
+		Duration:       time.Hour,
+		Volume:         0.5,
+		Width:          1920,
+		Height:         1080,
+		Aspect:         1.5,
+	}
+
+	data, err := json.Marshal(status)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"playbackStatus":"playing","playing":true,"positionMs":83250,"durationMs":3600000,` +
+		`"volume":0.5,"width":1920,"height":1080,"aspect":1.5}`
+	if string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+
+	var decoded Status
+	if err = json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	status.Position = status.Position.Truncate(time.Millisecond)
+	if decoded != status {
+		t.Errorf("got %+v after a round trip, want %+v", decoded, status)
+	}
+}
+
+func TestStatusJSONEmpty(t *testing.T) {
+	var status Status
+	if err := json.Unmarshal([]byte(`{"playbackStatus":""}`), &status); err != nil {
+		t.Fatal(err)
+	}
+	if status != (Status{}) {
+		t.Errorf("got %+v, want the zero Status", status)
+	}
+	if err := json.Unmarshal([]byte(`{"positionMs":"soon"}`), &status); err == nil {
+		t.Error("returned no error for a mistyped field")
+	}
+}
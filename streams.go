@@ -0,0 +1,151 @@
+package omxplayer
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const streamActive = "active"
+
+// Stream describes an audio, video or subtitle stream of the video, as listed
+// by ListAudio, ListVideo and ListSubtitles.
+type Stream struct {
+	// Index identifies the stream when selecting it, for example with
+	// SelectAudio.
+	Index int32
+
+	// Language is the language code of the stream, such as "eng", or empty if
+	// it is not known.
+	Language string
+
+	// Name is the title of the stream, such as "Director's commentary", or
+	// empty if it has none.
+	Name string
+
+	// Codec is the name of the codec the stream is encoded with, such as
+	// "ac3".
+	Codec string
+
+	// Active is true if the stream is the one being played.
+	Active bool
+}
+
+// AudioStream describes an audio stream of the video.
+type AudioStream = Stream
+
+// SubtitleStream describes a subtitle stream of the video.
+type SubtitleStream = Stream
+
+// VideoStream describes a video stream of the video.
+type VideoStream = Stream
+
+// AudioStreams returns the audio streams of the video, parsed from the list
+// returned by ListAudio.
+func (p *Player) AudioStreams() ([]AudioStream, error) {
+	return parseStreams(p.ListAudio())
+}
+
+// Subtitles returns the subtitle streams of the video, parsed from the list
+// returned by ListSubtitles.
+func (p *Player) Subtitles() ([]SubtitleStream, error) {
+	return parseStreams(p.ListSubtitles())
+}
+
+// VideoStreams returns the video streams of the video, parsed from the list
+// returned by ListVideo.
+func (p *Player) VideoStreams() ([]VideoStream, error) {
+	return parseStreams(p.ListVideo())
+}
+
+// parseStreams parses a stream list returned by omxplayer, passing through the
+// error of the call that returned it.
+func parseStreams(list []string, err error) ([]Stream, error) {
+	if err != nil {
+		return nil, err
+	}
+
+	streams := make([]Stream, 0, len(list))
+	for _, s := range list {
+		stream, err := parseStream(s)
+		if err != nil {
+			return nil, err
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
+
+// parseStream parses a stream as listed by omxplayer, in the form
+// "index:language:name:codec:active", such as "0:eng:English:ac3:active". The
+// last field is "active" for the active stream and empty for the others, as in
+// "1:ger:Deutsch:ac3:". The name may itself contain colons, and any field but
+// the index may be empty.
+func parseStream(s string) (Stream, error) {
+	fields := strings.Split(s, ":")
+
+	index, err := strconv.ParseInt(fields[0], 10, 32)
+	if err != nil {
+		return Stream{}, fmt.Errorf("omxplayer: invalid stream %q: %w", s, err)
+	}
+	stream := Stream{Index: int32(index)}
+	fields = fields[1:]
+
+	if n := len(fields); n > 0 {
+		stream.Active = fields[n-1] == streamActive
+		fields = fields[:n-1]
+	}
+	if len(fields) > 0 {
+		stream.Language = fields[0]
+		fields = fields[1:]
+	}
+	if n := len(fields); n > 1 {
+		stream.Codec = fields[n-1]
+		fields = fields[:n-1]
+	}
+	stream.Name = strings.Join(fields, ":")
+	return stream, nil
+}
+
+// SelectSubtitleByLang selects the first subtitle stream in the specified
+// language and returns the result of SelectSubtitle. The language may be given
+// as an ISO 639-1 or 639-2 code, such as "en" or "eng", in any case. If no
+// subtitle stream is in that language, false and an error wrapping
+// ErrTrackNotFound are returned.
+func (p *Player) SelectSubtitleByLang(lang string) (bool, error) {
+	streams, err := p.Subtitles()
+	if err != nil {
+		return false, err
+	}
+	stream, err := findStreamByLang(streams, lang)
+	if err != nil {
+		return false, err
+	}
+	return p.SelectSubtitle(stream.Index)
+}
+
+// SelectAudioByLang selects the audio stream in the specified language and
+// returns the result of SelectAudio. The language is matched like it is by
+// SelectSubtitleByLang. If several audio streams are in that language, the
+// first one listed by ListAudio is selected.
+func (p *Player) SelectAudioByLang(lang string) (bool, error) {
+	streams, err := p.AudioStreams()
+	if err != nil {
+		return false, err
+	}
+	stream, err := findStreamByLang(streams, lang)
+	if err != nil {
+		return false, err
+	}
+	return p.SelectAudio(stream.Index)
+}
+
+// findStreamByLang returns the first of streams in the specified language.
+func findStreamByLang(streams []Stream, lang string) (Stream, error) {
+	for _, stream := range streams {
+		if sameLanguage(stream.Language, lang) {
+			return stream, nil
+		}
+	}
+	return Stream{}, fmt.Errorf("%w: no stream in language %q", ErrTrackNotFound, lang)
+}
@@ -0,0 +1,109 @@
+package omxplayer
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseStream(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Stream
+	}{
+		{"0:eng:English:ac3:active", Stream{Index: 0, Language: "eng", Name: "English", Codec: "ac3", Active: true}},
+		{"1:ger:Deutsch:ac3:", Stream{Index: 1, Language: "ger", Name: "Deutsch", Codec: "ac3"}},
+		{"2:eng::subrip:", Stream{Index: 2, Language: "eng", Codec: "subrip"}},
+		{"3:und::h264:active", Stream{Index: 3, Language: "und", Codec: "h264", Active: true}},
+		{"4:eng:Commentary: The Director:aac:", Stream{Index: 4, Language: "eng", Name: "Commentary: The Director", Codec: "aac"}},
+		{"5::::", Stream{Index: 5}},
+	}
+
+	for _, tt := range tests {
+		got, err := parseStream(tt.in)
+		if err != nil {
+			t.Errorf("parseStream(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseStream(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseStreamInvalidIndex(t *testing.T) {
+	if _, err := parseStream("eng:English:ac3:active"); err == nil {
+		t.Error("got no error for a stream without an index")
+	}
+}
+
+func TestSubtitles(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdListSubtitles, []string{"0:eng:English:subrip:", "1:fre:Français:subrip:active"})
+	p := newTestPlayer(t, bus)
+
+	streams, err := p.Subtitles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []SubtitleStream{
+		{Index: 0, Language: "eng", Name: "English", Codec: "subrip"},
+		{Index: 1, Language: "fre", Name: "Français", Codec: "subrip", Active: true},
+	}
+	if len(streams) != len(want) {
+		t.Fatalf("got %d streams, want %d", len(streams), len(want))
+	}
+	for i := range want {
+		if streams[i] != want[i] {
+			t.Errorf("stream %d = %+v, want %+v", i, streams[i], want[i])
+		}
+	}
+}
+
+func TestSelectSubtitleByLang(t *testing.T) {
+	for _, lang := range []string{"fr", "fre", "fra", "FR"} {
+		bus := newFakeCaller()
+		bus.respond(cmdListSubtitles, []string{"0:eng:English:subrip:active", "1:fre:Français:subrip:", "2:fre:Forced:subrip:"})
+		bus.respond(cmdSelectSubtitle, true)
+		p := newTestPlayer(t, bus)
+
+		if ok, err := p.SelectSubtitleByLang(lang); err != nil || !ok {
+			t.Errorf("%s: got %t, %v, want true, nil", lang, ok, err)
+		}
+		if calls := bus.callsTo(cmdSelectSubtitle); len(calls) != 1 || calls[0][0] != int32(1) {
+			t.Errorf("%s: got SelectSubtitle calls %v, want one for stream 1", lang, calls)
+		}
+	}
+
+	bus := newFakeCaller()
+	bus.respond(cmdListSubtitles, []string{"0:eng:English:subrip:active"})
+	p := newTestPlayer(t, bus)
+	if ok, err := p.SelectSubtitleByLang("de"); ok || !errors.Is(err, ErrTrackNotFound) {
+		t.Errorf("got %t, %v, want false and ErrTrackNotFound", ok, err)
+	}
+	if n := len(bus.callsTo(cmdSelectSubtitle)); n != 0 {
+		t.Errorf("got %d SelectSubtitle calls without a matching stream, want 0", n)
+	}
+}
+
+func TestSelectAudioByLang(t *testing.T) {
+	for _, lang := range []string{"de", "ger", "deu", "DE"} {
+		bus := newFakeCaller()
+		bus.respond(cmdListAudio, []string{"0:eng:English:ac3:active", "1:ger:Deutsch:ac3:", "2:deu:Kommentar:aac:"})
+		bus.respond(cmdSelectAudio, true)
+		p := newTestPlayer(t, bus)
+
+		if ok, err := p.SelectAudioByLang(lang); err != nil || !ok {
+			t.Errorf("%s: got %t, %v, want true, nil", lang, ok, err)
+		}
+		if calls := bus.callsTo(cmdSelectAudio); len(calls) != 1 || calls[0][0] != int32(1) {
+			t.Errorf("%s: got SelectAudio calls %v, want one for stream 1", lang, calls)
+		}
+	}
+
+	bus := newFakeCaller()
+	bus.respond(cmdListAudio, []string{"0:eng:English:ac3:active"})
+	p := newTestPlayer(t, bus)
+	if ok, err := p.SelectAudioByLang("ja"); ok || !errors.Is(err, ErrTrackNotFound) {
+		t.Errorf("got %t, %v, want false and ErrTrackNotFound", ok, err)
+	}
+}
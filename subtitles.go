@@ -0,0 +1,119 @@
+package omxplayer
+
+import "time"
+
+// subtitleDelayStep is the amount by which each subtitle delay action shifts the
+// subtitles.
+const subtitleDelayStep = 250 * time.Millisecond
+
+// IncreaseSubtitleDelay shows subtitles 250ms later, like the f key does.
+func (p *Player) IncreaseSubtitleDelay() error {
+	return p.stepSubtitleDelay(ActionIncreaseSubtitleDelay, subtitleDelayStep)
+}
+
+// DecreaseSubtitleDelay shows subtitles 250ms earlier, like the d key does.
+func (p *Player) DecreaseSubtitleDelay() error {
+	return p.stepSubtitleDelay(ActionDecreaseSubtitleDelay, -subtitleDelayStep)
+}
+
+// SubtitleDelay returns the delay applied to subtitles through this Player.
+// omxplayer does not report the delay, so it is tracked from the calls made
+// through this Player, starting from zero when the player is launched.
+func (p *Player) SubtitleDelay() time.Duration {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.subtitleDelay
+}
+
+// AdjustSubtitleDelay shows subtitles later by delta, or earlier if delta is
+// negative, by sending the corresponding number of IncreaseSubtitleDelay or
+// DecreaseSubtitleDelay actions. omxplayer shifts subtitles in 250ms steps, so
+// delta is rounded to the nearest step, with halfway values rounded away from
+// zero.
+func (p *Player) AdjustSubtitleDelay(delta time.Duration) error {
+	steps := int(delta.Round(subtitleDelayStep) / subtitleDelayStep)
+	for ; steps > 0; steps-- {
+		if err := p.IncreaseSubtitleDelay(); err != nil {
+			return err
+		}
+	}
+	for ; steps < 0; steps++ {
+		if err := p.DecreaseSubtitleDelay(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SetSubtitleDelay delays subtitles by d, or shows them earlier if d is
+// negative, rounded to the nearest 250ms step. The delay is changed relative to
+// the one reported by SubtitleDelay, so it is only accurate if the delay has not
+// been changed by other means, such as the keyboard.
+func (p *Player) SetSubtitleDelay(d time.Duration) error {
+	return p.AdjustSubtitleDelay(d.Round(subtitleDelayStep) - p.SubtitleDelay())
+}
+
+// stepSubtitleDelay sends a subtitle delay action and records the resulting
+// change of the delay.
+func (p *Player) stepSubtitleDelay(action int32, delta time.Duration) error {
+	if err := p.Action(action); err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subtitleDelay += delta
+	return nil
+}
+
+// SubtitlesVisible returns true if subtitles are displayed. omxplayer does not
+// report whether they are, so until ShowSubtitles, HideSubtitles or
+// ToggleSubtitles is called, subtitles are assumed to be displayed if a subtitle
+// stream is active.
+func (p *Player) SubtitlesVisible() (bool, error) {
+	p.mu.RLock()
+	visible := p.subtitlesVisible
+	p.mu.RUnlock()
+	if visible != nil {
+		return *visible, nil
+	}
+
+	tracks, err := p.ListSubtitles()
+	if err != nil {
+		return false, err
+	}
+	_, active := activeTrack(tracks)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.subtitlesVisible == nil {
+		p.subtitlesVisible = &active
+	}
+	return *p.subtitlesVisible, nil
+}
+
+// ToggleSubtitles hides subtitles if SubtitlesVisible reports that they are
+// displayed, and shows them otherwise. It returns whether subtitles are
+// displayed afterwards.
+func (p *Player) ToggleSubtitles() (bool, error) {
+	visible, err := p.SubtitlesVisible()
+	if err != nil {
+		return false, err
+	}
+	if visible {
+		err = p.HideSubtitles()
+	} else {
+		err = p.ShowSubtitles()
+	}
+	if err != nil {
+		return visible, err
+	}
+	return !visible, nil
+}
+
+// setSubtitlesVisible records whether subtitles are displayed.
+func (p *Player) setSubtitlesVisible(visible bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.subtitlesVisible = &visible
+}
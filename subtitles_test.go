@@ -0,0 +1,95 @@
+package omxplayer
+
+import (
+	"testing"
+	"time"
+)
+
+// repeatAction returns n copies of action.
+func repeatAction(action int32, n int) []int32 {
+	actions := make([]int32, n)
+	for i := range actions {
+		actions[i] = action
+	}
+	return actions
+}
+
+func TestAdjustSubtitleDelay(t *testing.T) {
+	tests := []struct {
+		delta time.Duration
+		want  []int32
+		delay time.Duration
+	}{
+		{0, nil, 0},
+		{100 * time.Millisecond, nil, 0},
+		{250 * time.Millisecond, repeatAction(ActionIncreaseSubtitleDelay, 1), 250 * time.Millisecond},
+		{time.Second, repeatAction(ActionIncreaseSubtitleDelay, 4), time.Second},
+		{1125 * time.Millisecond, repeatAction(ActionIncreaseSubtitleDelay, 5), 1250 * time.Millisecond},
+		{1100 * time.Millisecond, repeatAction(ActionIncreaseSubtitleDelay, 4), time.Second},
+		{-500 * time.Millisecond, repeatAction(ActionDecreaseSubtitleDelay, 2), -500 * time.Millisecond},
+		{-125 * time.Millisecond, repeatAction(ActionDecreaseSubtitleDelay, 1), -250 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		bus := newFakeCaller()
+		bus.respond(cmdAction)
+		p := newTestPlayer(t, bus)
+
+		if err := p.AdjustSubtitleDelay(tt.delta); err != nil {
+			t.Errorf("%s: returned error: %v", tt.delta, err)
+			continue
+		}
+		if actions := bus.actions(); !equalActions(actions, tt.want) {
+			t.Errorf("%s: sent actions %v, want %v", tt.delta, actions, tt.want)
+		}
+		if delay := p.SubtitleDelay(); delay != tt.delay {
+			t.Errorf("%s: got SubtitleDelay %s, want %s", tt.delta, delay, tt.delay)
+		}
+	}
+}
+
+func TestSetSubtitleDelay(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdAction)
+	p := newTestPlayer(t, bus)
+
+	if err := p.SetSubtitleDelay(time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.SetSubtitleDelay(500 * time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	want := append(repeatAction(ActionIncreaseSubtitleDelay, 4), repeatAction(ActionDecreaseSubtitleDelay, 2)...)
+	if actions := bus.actions(); !equalActions(actions, want) {
+		t.Errorf("sent actions %v, want %v", actions, want)
+	}
+	if delay := p.SubtitleDelay(); delay != 500*time.Millisecond {
+		t.Errorf("got SubtitleDelay %s, want 500ms", delay)
+	}
+}
+
+func TestToggleSubtitles(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdListSubtitles, []string{"0:eng:English:subrip:active"})
+	bus.respond(cmdShowSubtitles)
+	bus.respond(cmdHideSubtitles)
+	p := newTestPlayer(t, bus)
+
+	for i, want := range []bool{false, true, false} {
+		visible, err := p.ToggleSubtitles()
+		if err != nil {
+			t.Fatalf("toggle %d: returned error: %v", i+1, err)
+		}
+		if visible != want {
+			t.Errorf("toggle %d: got visible %t, want %t", i+1, visible, want)
+		}
+		if visible, _ = p.SubtitlesVisible(); visible != want {
+			t.Errorf("toggle %d: got SubtitlesVisible %t, want %t", i+1, visible, want)
+		}
+	}
+	if shows, hides := len(bus.callsTo(cmdShowSubtitles)), len(bus.callsTo(cmdHideSubtitles)); shows != 1 || hides != 2 {
+		t.Errorf("got %d ShowSubtitles and %d HideSubtitles calls, want 1 and 2", shows, hides)
+	}
+	if n := len(bus.callsTo(cmdListSubtitles)); n != 1 {
+		t.Errorf("got %d ListSubtitles calls, want 1 since the state is tracked", n)
+	}
+}
@@ -0,0 +1,34 @@
+//go:build !windows
+// +build !windows
+
+package omxplayer
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const processGroupSupported = true
+
+// setProcessGroup makes the process started by cmd the leader of a new process
+// group, so that it can be signalled together with any processes it spawns.
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// signal sends the specified signal to the omxplayer process, or to its whole
+// process group if the player was launched with WithProcessGroup.
+func (p *Player) signal(sig syscall.Signal) error {
+	if p.command == nil {
+		return ErrNotLaunched
+	}
+	if p.config.processGroup {
+		return syscall.Kill(-p.command.Process.Pid, sig)
+	}
+	return p.command.Process.Signal(sig)
+}
+
+// makeFIFO creates a named pipe at the specified path.
+func makeFIFO(path string) error {
+	return syscall.Mkfifo(path, 0600)
+}
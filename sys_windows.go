@@ -0,0 +1,26 @@
+package omxplayer
+
+import (
+	"errors"
+	"os/exec"
+	"syscall"
+)
+
+const processGroupSupported = false
+
+// setProcessGroup does nothing, since process groups are not supported on
+// Windows.
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// signal sends the specified signal to the omxplayer process.
+func (p *Player) signal(sig syscall.Signal) error {
+	if p.command == nil {
+		return ErrNotLaunched
+	}
+	return p.command.Process.Signal(sig)
+}
+
+// makeFIFO returns an error, since named pipes cannot be created on Windows.
+func makeFIFO(path string) error {
+	return errors.New("omxplayer: named pipes are not supported on this platform")
+}
@@ -0,0 +1,292 @@
+package omxplayer
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	minVolumeMillibels = -6000
+	maxVolumeMillibels = 0
+
+	minAmplificationMillibels = -6000
+	maxAmplificationMillibels = 6000
+
+	millibelsPerDB = 100
+)
+
+// MillibelsToVolume converts a volume in millibels, as used by omxplayer's --vol
+// flag, to the linear scale used by the Volume method, where 1.0 is full volume.
+// It is DBToAmplitude of the volume in decibels.
+func MillibelsToVolume(millibels int) float64 {
+	return DBToAmplitude(float64(millibels) / millibelsPerDB)
+}
+
+// VolumeToMillibels converts a volume on the linear scale used by the Volume
+// method to millibels, as used by omxplayer's --vol flag, using AmplitudeToDB.
+// The result is rounded to the nearest millibel and clamped to the range
+// WithInitialVolume accepts: volumes of 0.001 or less, including zero, are
+// converted to -6000 millibels, and volumes above 1 to 0 millibels.
+func VolumeToMillibels(volume float64) int {
+	if volume <= 0 {
+		return minVolumeMillibels
+	}
+	millibels := int(math.Round(AmplitudeToDB(volume) * millibelsPerDB))
+	if millibels < minVolumeMillibels {
+		return minVolumeMillibels
+	}
+	if millibels > maxVolumeMillibels {
+		return maxVolumeMillibels
+	}
+	return millibels
+}
+
+// defaultVolumeStep is the amount by which VolumeUp and VolumeDown change the
+// volume unless another step is specified.
+const defaultVolumeStep = 0.05
+
+// defaultFadeInterval is the interval at which FadeVolume adjusts the volume
+// unless another one is set with WithFadeInterval.
+const defaultFadeInterval = 50 * time.Millisecond
+
+// MinVolumeDB is the default volume floor, in decibels, of SetVolumeDB and
+// VolumeDB: quieter volumes are treated as silence. Use WithVolumeFloor to
+// change it.
+const MinVolumeDB = -60.0
+
+// AmplitudeToDB converts a volume on the linear scale used by the Volume method
+// to decibels relative to full volume, using 20*log10(amplitude). Amplitudes of
+// zero or less are converted to negative infinity.
+func AmplitudeToDB(amplitude float64) float64 {
+	if amplitude <= 0 {
+		return math.Inf(-1)
+	}
+	return 20 * math.Log10(amplitude)
+}
+
+// DBToAmplitude converts a volume in decibels relative to full volume to the
+// linear scale used by the Volume method, using 10^(db/20). Negative infinity
+// is converted to zero.
+func DBToAmplitude(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+// SetVolumeDB sets the volume in decibels relative to full volume, converting it
+// with DBToAmplitude, and returns the linear volume reported by the player.
+// Volumes below the floor set with WithVolumeFloor, -60dB by default, are
+// raised to the floor rather than setting the volume to exactly zero, which
+// some omxplayer builds handle poorly.
+func (p *Player) SetVolumeDB(db float64) (float64, error) {
+	if floor := p.volumeFloor(); db < floor {
+		db = floor
+	}
+	return p.Volume(DBToAmplitude(db))
+}
+
+// VolumeDB returns the current volume in decibels relative to full volume. If
+// the volume is at or below the floor set with WithVolumeFloor, -60dB by
+// default, the floor is returned.
+func (p *Player) VolumeDB() (float64, error) {
+	volume, err := p.Volume()
+	if err != nil {
+		return 0, err
+	}
+	return math.Max(AmplitudeToDB(volume), p.volumeFloor()), nil
+}
+
+// volumeFloor returns the quietest volume, in decibels, used by SetVolumeDB and
+// VolumeDB.
+func (p *Player) volumeFloor() float64 {
+	if p.config.volumeFloor != 0 {
+		return p.config.volumeFloor
+	}
+	return MinVolumeDB
+}
+
+// IsMuted returns true if the audio is muted. omxplayer does not publish its
+// mute state over D-Bus, so the state is tracked from the calls to Mute and
+// Unmute made through this Player; it starts out unmuted and is not aware of
+// muting performed by other D-Bus clients. Setting the volume while muted does
+// not unmute the audio.
+func (p *Player) IsMuted() bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.muted
+}
+
+// Muted returns true if the audio is muted, like IsMuted. It was meant to read
+// omxplayer's mute state over D-Bus, but omxplayer does not publish one, so it
+// reports the state tracked by IsMuted and never returns an error.
+//
+// Deprecated: use IsMuted.
+func (p *Player) Muted() (bool, error) {
+	return p.IsMuted(), nil
+}
+
+// ToggleMute mutes the audio if it is not muted, and unmutes it otherwise. See
+// IsMuted for how the mute state is determined.
+func (p *Player) ToggleMute() error {
+	if p.IsMuted() {
+		return p.Unmute()
+	}
+	return p.Mute()
+}
+
+// recordMute records that the audio was muted.
+func (p *Player) recordMute() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.muted = true
+}
+
+// recordUnmute records that the audio was unmuted. If the volume was set while
+// the audio was muted, it returns the last volume that was set and true.
+func (p *Player) recordUnmute() (volume float64, changed bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	volume, changed = p.mutedVolume, p.volumeChanged
+	p.muted = false
+	p.volumeChanged = false
+	return volume, changed
+}
+
+// recordVolume records that the volume was set, so that Unmute applies it
+// again if the audio is muted.
+func (p *Player) recordVolume(volume float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.muted {
+		p.mutedVolume = volume
+		p.volumeChanged = true
+	}
+}
+
+// VolumeUp raises the volume by the specified step, or by 0.05 if no step is
+// specified, up to full volume, and returns the new volume. Concurrent calls to
+// VolumeUp and VolumeDown are applied one after the other, so none are lost.
+func (p *Player) VolumeUp(step ...float64) (float64, error) {
+	return p.changeVolume(volumeStep(step))
+}
+
+// VolumeDown lowers the volume by the specified step, or by 0.05 if no step is
+// specified, down to silence, and returns the new volume. See VolumeUp.
+func (p *Player) VolumeDown(step ...float64) (float64, error) {
+	return p.changeVolume(-volumeStep(step))
+}
+
+// changeVolume changes the volume by delta, clamped to between 0 and 1, and
+// returns the new volume.
+func (p *Player) changeVolume(delta float64) (float64, error) {
+	p.volumeMu.Lock()
+	defer p.volumeMu.Unlock()
+
+	volume, err := p.Volume()
+	if err != nil {
+		return 0, err
+	}
+	return p.Volume(math.Max(0, math.Min(1, volume+delta)))
+}
+
+// volumeStep returns the step passed to VolumeUp or VolumeDown, or the default
+// step if none was passed.
+func volumeStep(step []float64) float64 {
+	if len(step) == 0 {
+		return defaultVolumeStep
+	}
+	return math.Abs(step[0])
+}
+
+// FadeVolume changes the volume gradually from its current value to target over
+// the specified duration, adjusting it at the interval set with
+// WithFadeInterval, 50ms by default. It blocks until the volume reaches target,
+// which it is set to exactly, or until ctx is cancelled, in which case ctx.Err()
+// is returned and the volume is left where the fade stopped. Starting a fade
+// cancels any fade still in progress on the same Player, which then returns
+// context.Canceled. VolumeUp and VolumeDown do not cancel a fade: each step of
+// the fade waits for them to finish, and the next step overwrites their change.
+func (p *Player) FadeVolume(ctx context.Context, target float64, over time.Duration) error {
+	if target < 0 {
+		return fmt.Errorf("omxplayer: invalid fade target volume: %v", target)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	fade := p.startFade(cancel)
+	defer p.endFade(fade)
+
+	start, err := p.Volume()
+	if err != nil {
+		return err
+	}
+
+	interval := p.config.fadeInterval
+	if interval == 0 {
+		interval = defaultFadeInterval
+	}
+	steps := int(over / interval)
+	if steps < 1 {
+		return p.fadeStep(ctx, target)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for i := 1; i <= steps; i++ {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		volume := target
+		if i < steps {
+			volume = start + (target-start)*float64(i)/float64(steps)
+		}
+		if err = p.fadeStep(ctx, volume); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fadeStep sets the volume for a step of FadeVolume, unless ctx was cancelled
+// while it waited for a VolumeUp or VolumeDown call to finish.
+func (p *Player) fadeStep(ctx context.Context, volume float64) error {
+	p.volumeMu.Lock()
+	defer p.volumeMu.Unlock()
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	_, err := p.Volume(volume)
+	return err
+}
+
+// startFade cancels the fade in progress, if any, and records cancel as the
+// function that cancels the new one. It returns an identifier of the new fade.
+func (p *Player) startFade(cancel context.CancelFunc) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cancelFade != nil {
+		p.cancelFade()
+	}
+	p.cancelFade = cancel
+	p.fades++
+	return p.fades
+}
+
+// endFade forgets the fade with the specified identifier if it is still the one
+// in progress.
+func (p *Player) endFade(fade int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.fades == fade {
+		p.cancelFade = nil
+	}
+}
@@ -0,0 +1,473 @@
+package omxplayer
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"testing"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+// volumeWrites returns the volumes set through the Volume property, in order.
+func volumeWrites(bus *fakeCaller) []float64 {
+	var volumes []float64
+	for _, args := range bus.callsTo(cmdVolume) {
+		if len(args) > 0 {
+			volumes = append(volumes, args[0].(float64))
+		}
+	}
+	return volumes
+}
+
+func TestMuteUnmute(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdMute)
+	bus.respond(cmdUnmute)
+	bus.handleVolume(0.5)
+	p := newTestPlayer(t, bus)
+
+	if p.IsMuted() {
+		t.Fatal("player is muted before Mute was called")
+	}
+	if err := p.Mute(); err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsMuted() {
+		t.Error("player is not muted after Mute")
+	}
+	if err := p.Unmute(); err != nil {
+		t.Fatal(err)
+	}
+	if p.IsMuted() {
+		t.Error("player is muted after Unmute")
+	}
+
+	if writes := volumeWrites(bus); len(writes) != 0 {
+		t.Errorf("got volume writes %v, want none", writes)
+	}
+	if volume, _ := p.Volume(); volume != 0.5 {
+		t.Errorf("got volume %v after unmuting, want 0.5", volume)
+	}
+}
+
+func TestVolumeWhileMuted(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdMute)
+	bus.respond(cmdUnmute)
+	bus.handleVolume(0.5)
+	p := newTestPlayer(t, bus)
+
+	if err := p.Mute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Volume(0.3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Volume(0.2); err != nil {
+		t.Fatal(err)
+	}
+	if !p.IsMuted() {
+		t.Error("setting the volume unmuted the player")
+	}
+	if err := p.Unmute(); err != nil {
+		t.Fatal(err)
+	}
+
+	writes := volumeWrites(bus)
+	if want := []float64{0.3, 0.2, 0.2}; !equalFloats(writes, want) {
+		t.Errorf("got volume writes %v, want %v", writes, want)
+	}
+
+	if err := p.Mute(); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Unmute(); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(volumeWrites(bus)); n != len(writes) {
+		t.Errorf("volume was applied again after muting without changing it")
+	}
+}
+
+func TestUnmuteVolumeFailure(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdMute)
+	bus.respond(cmdUnmute)
+	bus.handleVolume(0.5)
+	p := newTestPlayer(t, bus)
+
+	if err := p.Mute(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Volume(0.2); err != nil {
+		t.Fatal(err)
+	}
+	bus.queue(cmdVolume, &dbus.Call{Err: dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"}})
+
+	if err := p.Unmute(); err != nil {
+		t.Errorf("got error %v, want the successful unmute to be reported", err)
+	}
+	if p.IsMuted() {
+		t.Error("player is muted after Unmute")
+	}
+}
+
+func TestVolumePassthrough(t *testing.T) {
+	bus := newFakeCaller()
+	bus.handleVolume(1)
+	p := newTestPlayer(t, bus, WithPassthrough())
+
+	if _, err := p.Volume(0.5); !errors.Is(err, ErrPassthrough) {
+		t.Errorf("got error %v, want ErrPassthrough", err)
+	}
+	if writes := volumeWrites(bus); len(writes) != 0 {
+		t.Errorf("got volume writes %v in passthrough mode, want none", writes)
+	}
+	if volume, err := p.Volume(); err != nil || volume != 1 {
+		t.Errorf("got volume %v, %v, want 1, nil", volume, err)
+	}
+}
+
+func TestUnmutePassthrough(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdMute)
+	bus.respond(cmdUnmute)
+	bus.handleVolume(1)
+	p := newTestPlayer(t, bus, WithPassthrough())
+
+	if err := p.Mute(); err != nil {
+		t.Fatal(err)
+	}
+	p.Volume(0.2)
+	if err := p.Unmute(); err != nil {
+		t.Errorf("got error %v, want nil", err)
+	}
+}
+
+func TestMuteConcurrent(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdMute)
+	bus.respond(cmdUnmute)
+	bus.handleVolume(0.5)
+	p := newTestPlayer(t, bus)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				switch (i + j) % 4 {
+				case 0:
+					p.Mute()
+				case 1:
+					p.Unmute()
+				case 2:
+					p.Volume(0.25)
+				default:
+					p.IsMuted()
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if err := p.Unmute(); err != nil {
+		t.Fatal(err)
+	}
+	if p.IsMuted() {
+		t.Error("player is muted after Unmute")
+	}
+}
+
+// equalFloats returns true if a and b hold the same values in the same order.
+func equalFloats(a, b []float64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestToggleMute(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(cmdMute)
+	bus.respond(cmdUnmute)
+	p := newTestPlayer(t, bus)
+
+	for i, want := range []bool{true, false, true} {
+		if err := p.ToggleMute(); err != nil {
+			t.Fatalf("toggle %d: returned error: %v", i+1, err)
+		}
+		if muted := p.IsMuted(); muted != want {
+			t.Errorf("toggle %d: got muted %t, want %t", i+1, muted, want)
+		}
+	}
+	if mutes, unmutes := len(bus.callsTo(cmdMute)), len(bus.callsTo(cmdUnmute)); mutes != 2 || unmutes != 1 {
+		t.Errorf("got %d Mute and %d Unmute calls, want 2 and 1", mutes, unmutes)
+	}
+
+	bus.fail(cmdUnmute, dbus.Error{Name: "org.freedesktop.DBus.Error.Failed"})
+	if err := p.ToggleMute(); err == nil {
+		t.Error("returned no error when Unmute failed")
+	}
+	if !p.IsMuted() {
+		t.Error("got unmuted after Unmute failed")
+	}
+}
+
+func TestVolumeUpDown(t *testing.T) {
+	bus := newFakeCaller()
+	bus.handleVolume(0.9)
+	p := newTestPlayer(t, bus)
+
+	steps := []struct {
+		fn   func(...float64) (float64, error)
+		step []float64
+		want float64
+	}{
+		{p.VolumeUp, nil, 0.95},
+		{p.VolumeUp, []float64{0.2}, 1},
+		{p.VolumeUp, nil, 1},
+		{p.VolumeDown, []float64{0.5}, 0.5},
+		{p.VolumeDown, []float64{-0.25}, 0.25},
+		{p.VolumeDown, []float64{0.3}, 0},
+		{p.VolumeDown, nil, 0},
+	}
+	for i, s := range steps {
+		volume, err := s.fn(s.step...)
+		if err != nil {
+			t.Fatalf("step %d: returned error: %v", i+1, err)
+		}
+		if math.Abs(volume-s.want) > 1e-9 {
+			t.Errorf("step %d: got volume %v, want %v", i+1, volume, s.want)
+		}
+	}
+}
+
+func TestDecibelConversions(t *testing.T) {
+	tests := []struct {
+		amplitude, db float64
+	}{
+		{1, 0},
+		{10, 20},
+		{0.1, -20},
+		{0.01, -40},
+		{0.001, -60},
+		{math.Sqrt(0.5), -10 * math.Log10(2)},
+	}
+	for _, tt := range tests {
+		if db := AmplitudeToDB(tt.amplitude); math.Abs(db-tt.db) > 1e-9 {
+			t.Errorf("AmplitudeToDB(%v) = %v, want %v", tt.amplitude, db, tt.db)
+		}
+		if amplitude := DBToAmplitude(tt.db); math.Abs(amplitude-tt.amplitude) > 1e-9 {
+			t.Errorf("DBToAmplitude(%v) = %v, want %v", tt.db, amplitude, tt.amplitude)
+		}
+	}
+
+	for _, millibels := range []int{0, -602, -2000, -6000} {
+		if volume, want := MillibelsToVolume(millibels), DBToAmplitude(float64(millibels)/100); volume != want {
+			t.Errorf("MillibelsToVolume(%d) = %v, want DBToAmplitude(%v) = %v", millibels, volume, float64(millibels)/100, want)
+		}
+	}
+
+	if db := AmplitudeToDB(0.5); math.Abs(db+6.0206) > 1e-4 {
+		t.Errorf("AmplitudeToDB(0.5) = %v, want about -6.0206", db)
+	}
+	for _, amplitude := range []float64{0, -1} {
+		if db := AmplitudeToDB(amplitude); !math.IsInf(db, -1) {
+			t.Errorf("AmplitudeToDB(%v) = %v, want -Inf", amplitude, db)
+		}
+	}
+	if amplitude := DBToAmplitude(math.Inf(-1)); amplitude != 0 {
+		t.Errorf("DBToAmplitude(-Inf) = %v, want 0", amplitude)
+	}
+}
+
+func TestVolumeDB(t *testing.T) {
+	bus := newFakeCaller()
+	bus.handleVolume(1)
+	p := newTestPlayer(t, bus)
+
+	if volume, err := p.SetVolumeDB(-20); err != nil || math.Abs(volume-0.1) > 1e-9 {
+		t.Errorf("SetVolumeDB(-20) = %v, %v, want 0.1, nil", volume, err)
+	}
+	if db, err := p.VolumeDB(); err != nil || math.Abs(db+20) > 1e-9 {
+		t.Errorf("got %v, %v, want -20, nil", db, err)
+	}
+	if volume, err := p.SetVolumeDB(math.Inf(-1)); err != nil || math.Abs(volume-0.001) > 1e-9 {
+		t.Errorf("SetVolumeDB(-Inf) = %v, %v, want the -60dB floor", volume, err)
+	}
+
+	bus.handleVolume(0)
+	if db, err := p.VolumeDB(); err != nil || db != MinVolumeDB {
+		t.Errorf("got %v, %v for silence, want %v, nil", db, err, MinVolumeDB)
+	}
+
+	p = newTestPlayer(t, bus, WithVolumeFloor(-40))
+	if volume, err := p.SetVolumeDB(-50); err != nil || math.Abs(volume-0.01) > 1e-9 {
+		t.Errorf("SetVolumeDB(-50) = %v, %v, want the -40dB floor", volume, err)
+	}
+}
+
+func TestFadeVolume(t *testing.T) {
+	tests := []struct {
+		start, target float64
+	}{
+		{0.2, 1},
+		{1, 0},
+		{0.5, 0.5},
+	}
+	for _, tt := range tests {
+		bus := newFakeCaller()
+		bus.handleVolume(tt.start)
+		p := newTestPlayer(t, bus, WithFadeInterval(time.Millisecond))
+
+		if err := p.FadeVolume(context.Background(), tt.target, 10*time.Millisecond); err != nil {
+			t.Errorf("%v to %v: returned error: %v", tt.start, tt.target, err)
+			continue
+		}
+		writes := volumeWrites(bus)
+		if len(writes) != 10 {
+			t.Errorf("%v to %v: got %d volume writes, want 10", tt.start, tt.target, len(writes))
+		}
+		previous := tt.start
+		for _, volume := range writes {
+			if tt.target >= tt.start && volume < previous || tt.target < tt.start && volume > previous {
+				t.Errorf("%v to %v: volumes %v do not progress towards the target", tt.start, tt.target, writes)
+				break
+			}
+			previous = volume
+		}
+		if len(writes) > 0 && writes[len(writes)-1] != tt.target {
+			t.Errorf("%v to %v: got final volume %v", tt.start, tt.target, writes[len(writes)-1])
+		}
+	}
+}
+
+func TestFadeVolumeShort(t *testing.T) {
+	bus := newFakeCaller()
+	bus.handleVolume(1)
+	p := newTestPlayer(t, bus)
+
+	if err := p.FadeVolume(context.Background(), 0.25, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	if writes := volumeWrites(bus); !equalFloats(writes, []float64{0.25}) {
+		t.Errorf("got volume writes %v, want [0.25]", writes)
+	}
+	if err := p.FadeVolume(context.Background(), -1, time.Second); err == nil {
+		t.Error("returned no error for a negative target")
+	}
+}
+
+func TestFadeVolumeCancelled(t *testing.T) {
+	bus := newFakeCaller()
+	bus.handleVolume(1)
+	p := newTestPlayer(t, bus, WithFadeInterval(time.Millisecond))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if err := p.FadeVolume(ctx, 0, time.Minute); err != context.DeadlineExceeded {
+		t.Errorf("got error %v, want context.DeadlineExceeded", err)
+	}
+	if writes := volumeWrites(bus); len(writes) > 0 && writes[len(writes)-1] == 0 {
+		t.Errorf("got volume writes %v, want the fade to stop before the target", writes)
+	}
+
+	written := len(volumeWrites(bus))
+	done := make(chan error)
+	go func() {
+		done <- p.FadeVolume(context.Background(), 0, time.Minute)
+	}()
+	for deadline := time.Now().Add(5 * time.Second); len(volumeWrites(bus)) == written; {
+		if time.Now().After(deadline) {
+			t.Fatal("the first fade did not start")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if err := p.FadeVolume(context.Background(), 1, time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-done:
+		if err != context.Canceled {
+			t.Errorf("got error %v from the superseded fade, want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the superseded fade did not return")
+	}
+}
+
+func TestFadeVolumeWaitsForVolumeChange(t *testing.T) {
+	bus := newFakeCaller()
+	bus.handleVolume(0.2)
+	p := newTestPlayer(t, bus, WithFadeInterval(time.Millisecond))
+
+	p.volumeMu.Lock()
+	done := make(chan error)
+	go func() {
+		done <- p.FadeVolume(context.Background(), 1, 10*time.Millisecond)
+	}()
+	time.Sleep(20 * time.Millisecond)
+	if writes := volumeWrites(bus); len(writes) != 0 {
+		t.Errorf("got volume writes %v while a volume change was in progress, want none", writes)
+	}
+	p.volumeMu.Unlock()
+
+	for i := 0; i < 5; i++ {
+		if _, err := p.VolumeDown(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the fade did not finish")
+	}
+	if volume, err := p.Volume(); err != nil || volume != 1 {
+		t.Errorf("got volume %v, %v, want the fade to end at 1", volume, err)
+	}
+}
+
+func TestVolumeToMillibels(t *testing.T) {
+	tests := []struct {
+		volume    float64
+		millibels int
+	}{
+		{1, 0},
+		{0.1, -2000},
+		{0.5, -602},
+		{0.001, -6000},
+		{0.0001, -6000},
+		{0, -6000},
+		{-1, -6000},
+		{1.0001, 0},
+		{2, 0},
+	}
+	for _, tt := range tests {
+		millibels := VolumeToMillibels(tt.volume)
+		if millibels != tt.millibels {
+			t.Errorf("VolumeToMillibels(%v) = %d, want %d", tt.volume, millibels, tt.millibels)
+		}
+		if err := WithInitialVolume(millibels)(&Config{}); err != nil {
+			t.Errorf("VolumeToMillibels(%v) = %d, which WithInitialVolume rejects: %v", tt.volume, millibels, err)
+		}
+	}
+
+	for _, millibels := range []int{0, -602, -2000, -6000} {
+		if got := VolumeToMillibels(MillibelsToVolume(millibels)); got != millibels {
+			t.Errorf("got %d after a round trip of %d millibels", got, millibels)
+		}
+	}
+}
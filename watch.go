@@ -0,0 +1,148 @@
+package omxplayer
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	displayPollInterval = time.Second
+
+	// defaultStatusInterval is the interval at which StatusChanges polls the
+	// playback status when it is given an interval that is not positive.
+	defaultStatusInterval = time.Second
+
+	// fileDisplaySize holds the size of the framebuffer of the primary display,
+	// which the firmware resizes whenever the display mode changes, as
+	// "width,height".
+	fileDisplaySize = "/sys/class/graphics/fb0/virtual_size"
+)
+
+// Resolution describes the width and height of a display, in pixels.
+type Resolution struct {
+	Width  int64
+	Height int64
+}
+
+// OnDisplayChange polls the resolution of the display once a second and sends
+// the new resolution through the returned channel whenever it changes, for
+// example because the TV connected over HDMI switched modes, so that the video
+// window geometry can be reapplied. The resolution is read from the framebuffer
+// of the primary display in sysfs, as omxplayer only reports the resolution of
+// the video; if it cannot be read, no changes are sent. The channel is closed
+// when ctx is cancelled.
+func (p *Player) OnDisplayChange(ctx context.Context) <-chan Resolution {
+	changes := make(chan Resolution)
+
+	go func() {
+		defer close(changes)
+
+		ticker := time.NewTicker(displayPollInterval)
+		defer ticker.Stop()
+
+		last, _ := displayResolution()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			res, err := displayResolution()
+			if err != nil || res == last {
+				continue
+			}
+			last = res
+
+			select {
+			case changes <- res:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return changes
+}
+
+// displayResolution reads the current resolution of the primary display.
+func displayResolution() (Resolution, error) {
+	data, err := ioutil.ReadFile(fileDisplaySize)
+	if err != nil {
+		return Resolution{}, err
+	}
+	return parseDisplaySize(string(data))
+}
+
+// parseDisplaySize parses the size of a framebuffer as reported by sysfs, such
+// as "1920,1080".
+func parseDisplaySize(s string) (Resolution, error) {
+	fields := strings.Split(strings.TrimSpace(s), ",")
+	if len(fields) != 2 {
+		return Resolution{}, fmt.Errorf("omxplayer: invalid display size: %q", s)
+	}
+
+	width, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return Resolution{}, fmt.Errorf("omxplayer: invalid display size: %q", s)
+	}
+	height, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil {
+		return Resolution{}, fmt.Errorf("omxplayer: invalid display size: %q", s)
+	}
+	return Resolution{Width: width, Height: height}, nil
+}
+
+// StatusChanges polls the player's playback status at the specified interval
+// and sends the new status through the returned channel whenever it changes,
+// for example from "Playing" to "Paused". The channel is buffered; if the
+// consumer falls behind, a pending status that has not been received yet is
+// replaced by the latest one rather than blocking the poller. The channel is
+// closed when ctx is cancelled or the omxplayer process exits. If interval is
+// not positive, the status is polled once a second.
+func (p *Player) StatusChanges(ctx context.Context, interval time.Duration) <-chan string {
+	if interval <= 0 {
+		interval = defaultStatusInterval
+	}
+	changes := make(chan string, 1)
+
+	go func() {
+		defer close(changes)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last, _ := p.PlaybackStatus()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-p.exited:
+				return
+			case <-ticker.C:
+			}
+
+			status, err := p.PlaybackStatus()
+			if err != nil || status == last {
+				continue
+			}
+			last = status
+
+			select {
+			case changes <- status:
+			default:
+				select {
+				case <-changes:
+				default:
+				}
+				changes <- status
+			}
+		}
+	}()
+
+	return changes
+}
@@ -0,0 +1,89 @@
+package omxplayer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	dbus "github.com/godbus/dbus/v5"
+)
+
+func TestParseDisplaySize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Resolution
+	}{
+		{"1920,1080\n", Resolution{Width: 1920, Height: 1080}},
+		{"3840,2160", Resolution{Width: 3840, Height: 2160}},
+		{" 720,576 \n", Resolution{Width: 720, Height: 576}},
+	}
+	for _, tt := range tests {
+		got, err := parseDisplaySize(tt.in)
+		if err != nil {
+			t.Errorf("parseDisplaySize(%q) returned error: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseDisplaySize(%q) = %+v, want %+v", tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"", "1920", "1920x1080", "1920,", "a,b", "1920,1080,32"} {
+		if _, err := parseDisplaySize(in); err == nil {
+			t.Errorf("parseDisplaySize(%q) returned no error", in)
+		}
+	}
+}
+
+func TestStatusChanges(t *testing.T) {
+	bus := newFakeCaller()
+	bus.queue(propPlaybackStatus, &dbus.Call{Body: []interface{}{"Playing"}})
+	bus.respond(propPlaybackStatus, "Paused")
+	p := newTestPlayer(t, bus)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := p.StatusChanges(ctx, 10*time.Millisecond)
+
+	select {
+	case status := <-changes:
+		if status != "Paused" {
+			t.Errorf("got status %q, want Paused", status)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no status change was sent")
+	}
+
+	cancel()
+	for range changes {
+	}
+}
+
+func TestStatusChangesDefaultInterval(t *testing.T) {
+	bus := newFakeCaller()
+	bus.respond(propPlaybackStatus, "Playing")
+	p := newTestPlayer(t, bus)
+
+	for _, interval := range []time.Duration{0, -time.Second} {
+		ctx, cancel := context.WithCancel(context.Background())
+		changes := p.StatusChanges(ctx, interval)
+		cancel()
+
+		select {
+		case <-waitClosed(changes):
+		case <-time.After(time.Second):
+			t.Fatalf("channel was not closed after cancelling, interval %s", interval)
+		}
+	}
+}
+
+// waitClosed returns a channel that is closed once changes is closed.
+func waitClosed(changes <-chan string) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range changes {
+		}
+	}()
+	return done
+}